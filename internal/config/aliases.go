@@ -0,0 +1,24 @@
+package config
+
+import (
+	_ "embed"
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+)
+
+//go:embed aliases.yml
+var arcTitleAliasesYAML []byte
+
+// ArcTitleAliases maps arc titles that don't match an arc's sheet title to
+// that title, for joining them to arcs by name. Loaded from the
+// source-controlled aliases.yml; see parse.NewTitleAliases.
+var ArcTitleAliases = mustLoadAliases(arcTitleAliasesYAML)
+
+func mustLoadAliases(data []byte) map[string]string {
+	aliases := map[string]string{}
+	if err := yaml.Unmarshal(data, &aliases); err != nil {
+		panic(fmt.Sprintf("config/aliases.yml: %v", err))
+	}
+	return aliases
+}
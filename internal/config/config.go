@@ -1,6 +1,90 @@
 package config
 
+import "time"
+
 var (
 	OnePaceEpisodeGuide  = "1HQRMJgu_zArp-sLnvFMDzOyjdsht87eFLECxMK858lA"
 	OnePaceEpisodeDescID = "1M0Aa2p5x7NioaH9-u8FyHq6rH3t5s6Sccs8GoC6pHAM"
 )
+
+// OnePaceEpisodeDescLanguageGIDs lists extra tabs of the descriptions
+// sheet that each hold one language's titles and descriptions, by language
+// tag -> tab gid. They share the main tab's layout (arc title, part, title,
+// description); whatever language suffix their headers carry, a tab's
+// text is read as the language it's listed under. Language columns on the
+// main tab ("title_es", "description_es") are picked up without being
+// listed here.
+var OnePaceEpisodeDescLanguageGIDs = map[string]string{}
+
+// How long a Nyaa search result for a CRC32 is reused before Nyaa is asked
+// again (see fetch.NyaaCache, kept in data/cache/nyaa.json). Misses expire
+// sooner, since a torrent can turn up after the sheet lists its CRC.
+var (
+	NyaaCacheTTL         = 7 * 24 * time.Hour
+	NyaaNegativeCacheTTL = 24 * time.Hour
+)
+
+// Sheet layout drift policies (SheetDriftPolicy).
+const (
+	DriftFail     = "fail"
+	DriftContinue = "continue"
+)
+
+// SheetDriftPolicy is what an export does when the arc list's or an arc
+// tab's header row or column count differs from the last run's (recorded
+// in data/sheet-layouts.json): DriftFail stops after writing the run
+// report, before any data is exported or the recorded layouts updated;
+// DriftContinue exports anyway and records the new layouts. Either way the
+// changes are listed in reports/guide.json. Overridden by "export -drift".
+var SheetDriftPolicy = DriftFail
+
+// IDs used by the anime-lists style cross-reference export: the original
+// One Piece series on AniDB, and the TVDB series its episodes are mapped
+// onto. One Pace itself has no TVDB entry, and anime-lists consumers skip
+// entries without a real ID, so anime-list.xml isn't written until
+// OnePaceTVDBID is set (here or with "export -tvdb").
+var (
+	OnePieceAniDBID = 69
+	OnePaceTVDBID   = ""
+)
+
+// MagnetTrackers are trackers always added back to magnet links on output,
+// even before any release has announced them. Stored magnets are compact
+// (infohash + filename only); every tracker ever seen is recorded in
+// data/trackers.json, and output magnets get all of those too, less
+// TrackerDenylist.
+var MagnetTrackers = []string{
+	"http://nyaa.tracker.wf:7777/announce",
+	"udp://tracker.opentrackr.org:1337/announce",
+	"udp://open.stealth.si:80/announce",
+	"udp://exodus.desync.com:6969/announce",
+	"udp://tracker.torrent.eu.org:451/announce",
+	"udp://explodie.org:6969/announce",
+}
+
+// TrackerDenylist are the hosts of trackers left out of output magnets even
+// though releases announce them (data/trackers.json lists them as
+// inactive). Matched by host, so every scheme, port and path a release
+// spelled a tracker with is covered. A dead tracker goes here: removing it
+// from MagnetTrackers isn't enough once a release has announced it.
+var TrackerDenylist = []string{
+	"tracker.coppersurfer.tk",
+	"opentracker.i2p.rocks",
+	"tracker.openbittorrent.com",
+	"tracker.publicbt.com",
+	"tracker.leechers-paradise.org",
+	"zer0day.ch",
+	"anidex.moe",
+	"open.nyaatorrents.info",
+	"1.track.ga",
+	"tracker.open-internet.nl",
+	"tracker1.520.jp",
+	"bt.endpot.com",
+	"tracker.tiny-vps.com",
+	"tracker1.bt.moack.co.kr",
+	"retracker01-msk-virt.corbina.net",
+	"movies.zsw.ca",
+	"tr.burnabyhighstar.com",
+	"tracker.gbitt.info",
+	"tracker-udp.gbitt.info",
+}
@@ -0,0 +1,10 @@
+package config
+
+import _ "embed"
+
+// SagaMapping is the source-controlled arc -> saga grouping, a list of
+// {title, arcs} entries. It's the fallback for arcs the episode
+// descriptions sheet doesn't assign a saga.
+//
+//go:embed sagas.yml
+var SagaMapping []byte
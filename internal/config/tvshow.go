@@ -0,0 +1,10 @@
+package config
+
+import _ "embed"
+
+// TVShowTemplate is the source-controlled, hand-edited part of
+// data/tvshow.{json,yml} (title, plot, genres, ...). The exporter layers
+// live facts derived from the scraped arcs on top of it.
+//
+//go:embed tvshow.yml
+var TVShowTemplate []byte
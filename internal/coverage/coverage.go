@@ -0,0 +1,163 @@
+// Package coverage checks the episode guide's chapter bookkeeping: for each
+// arc, which declared manga chapters no episode covers, which chapters more
+// than one episode covers, and the same questions across arc boundaries.
+// It mostly catches data-entry mistakes in the sheet, and documents which
+// chapters One Pace genuinely skips.
+package coverage
+
+import (
+	"sort"
+	"strings"
+
+	"metadata-service/internal/model"
+	"metadata-service/internal/parse"
+)
+
+// Analyze builds a coverage report from arcs. It uses the parsed
+// MangaChapterRefs/ChapterRefs, re-parsing the raw strings for data exported
+// before those existed. Arcs with no episodes yet are listed but not
+// checked — every chapter would be a gap.
+func Analyze(arcs []model.Arc) model.CoverageReport {
+	var report model.CoverageReport
+
+	// chapter -> arcs whose episodes cover it, for the cross-arc checks.
+	arcsByChapter := make(map[int][]string)
+
+	for _, arc := range arcs {
+		ac := model.ArcCoverage{ArcID: arc.ID, Arc: arc.Arc, Title: arc.Title}
+
+		declared := make(map[int]bool)
+		arcRefs := arc.MangaChapterRefs
+		if arcRefs == nil {
+			arcRefs = parse.References(arc.MangaChapters, model.SegmentMangaChapter)
+		}
+		addChapters(arcRefs, declared, &ac.Unparsed, arc.MangaChapters)
+		ac.Declared = compress(declared)
+
+		// chapter -> episodes of this arc covering it
+		episodesByChapter := make(map[int][]string)
+		for _, ep := range arc.Episodes {
+			refs := ep.ChapterRefs
+			if refs == nil {
+				refs = parse.References(ep.Chapters, model.SegmentMangaChapter)
+			}
+			covered := make(map[int]bool)
+			addChapters(refs, covered, &ac.Unparsed, ep.Chapters)
+			for ch := range covered {
+				episodesByChapter[ch] = append(episodesByChapter[ch], ep.ID)
+			}
+		}
+
+		if len(arc.Episodes) > 0 {
+			gaps := make(map[int]bool)
+			for ch := range declared {
+				if _, ok := episodesByChapter[ch]; !ok {
+					gaps[ch] = true
+				}
+			}
+			ac.Gaps = compress(gaps)
+
+			outside := make(map[int]bool)
+			shared := make(map[int][]string)
+			for ch, ids := range episodesByChapter {
+				if len(declared) > 0 && !declared[ch] {
+					outside[ch] = true
+				}
+				if len(ids) > 1 {
+					shared[ch] = ids
+				}
+				arcsByChapter[ch] = append(arcsByChapter[ch], arc.ID)
+			}
+			ac.OutOfRange = compress(outside)
+			ac.Overlaps = groupOverlaps(shared, func(o *model.ChapterOverlap, ids []string) { o.EpisodeIDs = ids })
+		}
+
+		report.Arcs = append(report.Arcs, ac)
+	}
+
+	crossArc := make(map[int][]string)
+	maxChapter := 0
+	for ch, ids := range arcsByChapter {
+		if len(ids) > 1 {
+			crossArc[ch] = ids
+		}
+		maxChapter = max(maxChapter, ch)
+	}
+	report.CrossArcOverlaps = groupOverlaps(crossArc, func(o *model.ChapterOverlap, ids []string) { o.ArcIDs = ids })
+
+	skipped := make(map[int]bool)
+	for ch := 1; ch <= maxChapter; ch++ {
+		if _, ok := arcsByChapter[ch]; !ok {
+			skipped[ch] = true
+		}
+	}
+	report.Skipped = compress(skipped)
+
+	return report
+}
+
+// addChapters adds every numbered manga-chapter segment of refs to set,
+// and records raw (the source text) in unparsed when refs has pieces that
+// couldn't be read.
+func addChapters(refs *model.References, set map[int]bool, unparsed *[]string, raw string) {
+	if refs == nil {
+		return
+	}
+	for _, seg := range refs.Segments {
+		if seg.Kind != model.SegmentMangaChapter {
+			continue
+		}
+		for n := seg.Start; n <= seg.End; n++ {
+			set[n] = true
+		}
+	}
+	if len(refs.Unparsed) > 0 {
+		*unparsed = append(*unparsed, raw)
+	}
+}
+
+// compress turns a set of chapter numbers into sorted, maximal ranges.
+func compress(set map[int]bool) []model.ChapterRange {
+	nums := make([]int, 0, len(set))
+	for n := range set {
+		nums = append(nums, n)
+	}
+	sort.Ints(nums)
+
+	var out []model.ChapterRange
+	for _, n := range nums {
+		if last := len(out) - 1; last >= 0 && out[last].End == n-1 {
+			out[last].End = n
+			continue
+		}
+		out = append(out, model.ChapterRange{Start: n, End: n})
+	}
+	return out
+}
+
+// groupOverlaps merges consecutive chapters shared by the same set of IDs
+// into a single ChapterOverlap; set stores the IDs on the right field.
+func groupOverlaps(shared map[int][]string, set func(*model.ChapterOverlap, []string)) []model.ChapterOverlap {
+	nums := make([]int, 0, len(shared))
+	for n := range shared {
+		nums = append(nums, n)
+	}
+	sort.Ints(nums)
+
+	var out []model.ChapterOverlap
+	lastKey := ""
+	for _, n := range nums {
+		ids := append([]string(nil), shared[n]...)
+		sort.Strings(ids)
+		key := strings.Join(ids, "\x00")
+		if last := len(out) - 1; last >= 0 && key == lastKey && out[last].Chapters.End == n-1 {
+			out[last].Chapters.End = n
+			continue
+		}
+		o := model.ChapterOverlap{Chapters: model.ChapterRange{Start: n, End: n}}
+		set(&o, ids)
+		out = append(out, o)
+		lastKey = key
+	}
+	return out
+}
@@ -0,0 +1,64 @@
+package coverage
+
+import (
+	"reflect"
+	"testing"
+
+	"metadata-service/internal/model"
+)
+
+func TestAnalyze(t *testing.T) {
+	arcs := []model.Arc{
+		{
+			ID: "rd", Title: "Romance Dawn", MangaChapters: "1 - 7",
+			Episodes: []model.Episode{
+				{ID: "rd-001", Chapters: "Ch. 1-3"},
+				{ID: "rd-002", Chapters: "Ch. 3-4"},
+				// 5-6 missing; 8 is outside the arc's declared range.
+				{ID: "rd-003", Chapters: "Ch. 7-8"},
+			},
+		},
+		{
+			ID: "ot", Title: "Orange Town", MangaChapters: "8 - 12",
+			Episodes: []model.Episode{
+				{ID: "ot-001", Chapters: "Ch. 8-9"},
+				// 10 is skipped by the whole series.
+				{ID: "ot-002", Chapters: "Ch. 11-12, ???"},
+			},
+		},
+		{ID: "tbr", Title: "Unreleased", MangaChapters: "13 - 20"},
+	}
+
+	report := Analyze(arcs)
+	rd, ot, tbr := report.Arcs[0], report.Arcs[1], report.Arcs[2]
+
+	if want := []model.ChapterRange{{Start: 5, End: 6}}; !reflect.DeepEqual(rd.Gaps, want) {
+		t.Errorf("rd gaps = %v, want %v", rd.Gaps, want)
+	}
+	wantOverlap := []model.ChapterOverlap{{Chapters: model.ChapterRange{Start: 3, End: 3}, EpisodeIDs: []string{"rd-001", "rd-002"}}}
+	if !reflect.DeepEqual(rd.Overlaps, wantOverlap) {
+		t.Errorf("rd overlaps = %+v, want %+v", rd.Overlaps, wantOverlap)
+	}
+	if want := []model.ChapterRange{{Start: 8, End: 8}}; !reflect.DeepEqual(rd.OutOfRange, want) {
+		t.Errorf("rd out of range = %v, want %v", rd.OutOfRange, want)
+	}
+
+	if want := []model.ChapterRange{{Start: 10, End: 10}}; !reflect.DeepEqual(ot.Gaps, want) {
+		t.Errorf("ot gaps = %v, want %v", ot.Gaps, want)
+	}
+	if want := []string{"Ch. 11-12, ???"}; !reflect.DeepEqual(ot.Unparsed, want) {
+		t.Errorf("ot unparsed = %v, want %v", ot.Unparsed, want)
+	}
+
+	if tbr.Gaps != nil {
+		t.Errorf("arc with no episodes shouldn't report gaps, got %v", tbr.Gaps)
+	}
+
+	wantCross := []model.ChapterOverlap{{Chapters: model.ChapterRange{Start: 8, End: 8}, ArcIDs: []string{"ot", "rd"}}}
+	if !reflect.DeepEqual(report.CrossArcOverlaps, wantCross) {
+		t.Errorf("cross-arc = %+v, want %+v", report.CrossArcOverlaps, wantCross)
+	}
+	if want := []model.ChapterRange{{Start: 5, End: 6}, {Start: 10, End: 10}}; !reflect.DeepEqual(report.Skipped, want) {
+		t.Errorf("skipped = %v, want %v", report.Skipped, want)
+	}
+}
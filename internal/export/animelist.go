@@ -0,0 +1,147 @@
+package export
+
+import (
+	"encoding/xml"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"metadata-service/internal/config"
+	"metadata-service/internal/model"
+)
+
+// buildAnimeMapping cross-references every arc/episode with the original
+// One Piece episodes it adapts, from the numbered anime-episode segments of
+// Episode.AnimeEpisodeRefs (the same ones the reverse index uses) and
+// Arc.AnimeEpisodeRange.
+func buildAnimeMapping(arcs []model.Arc) model.AnimeMapping {
+	m := model.AnimeMapping{
+		AniDBID: config.OnePieceAniDBID,
+		TVDBID:  config.OnePaceTVDBID,
+		Arcs:    make([]model.ArcMapping, 0, len(arcs)),
+	}
+	for _, arc := range arcs {
+		am := model.ArcMapping{
+			ArcID:             arc.ID,
+			Arc:               arc.Arc,
+			Title:             arc.Title,
+			AnimeEpisodes:     arc.AnimeEpisodes,
+			AnimeEpisodeRange: arc.AnimeEpisodeRange,
+			Episodes:          make([]model.EpisodeMapping, 0, len(arc.Episodes)),
+		}
+		for _, ep := range arc.Episodes {
+			am.Episodes = append(am.Episodes, model.EpisodeMapping{
+				EpisodeID:     ep.ID,
+				Episode:       ep.Episode,
+				AnimeEps:      ep.AnimeEps,
+				AnimeEpisodes: animeEpisodeNumbers(ep.AnimeEpisodeRefs),
+			})
+		}
+		m.Arcs = append(m.Arcs, am)
+	}
+	return m
+}
+
+// animeEpisodeNumbers lists the anime episodes refs' numbered segments
+// cover, in order and without repeats. Specials and manga chapters aren't
+// original episodes, so they're left out.
+func animeEpisodeNumbers(refs *model.References) []int {
+	if refs == nil {
+		return nil
+	}
+	var numbers []int
+	for _, seg := range refs.Segments {
+		if seg.Kind != model.SegmentAnimeEpisode {
+			continue
+		}
+		for n := seg.Start; n <= seg.End; n++ {
+			if !slices.Contains(numbers, n) {
+				numbers = append(numbers, n)
+			}
+		}
+	}
+	return numbers
+}
+
+//
+// ===== anime-lists XML shape =====
+//
+
+type animeList struct {
+	XMLName xml.Name         `xml:"anime-list"`
+	Anime   []animeListEntry `xml:"anime"`
+}
+
+type animeListEntry struct {
+	AniDBID           int                `xml:"anidbid,attr"`
+	TVDBID            string             `xml:"tvdbid,attr"`
+	DefaultTVDBSeason string             `xml:"defaulttvdbseason,attr"`
+	Name              string             `xml:"name"`
+	Mappings          []animeListMapping `xml:"mapping-list>mapping"`
+}
+
+// animeListMapping is one anime-lists <mapping>: the text lists
+// ";anidbEp-tvdbEp;" pairs, with "+" joining several One Pace episodes
+// that share one original episode.
+type animeListMapping struct {
+	AniDBSeason int    `xml:"anidbseason,attr"`
+	TVDBSeason  int    `xml:"tvdbseason,attr"`
+	Pairs       string `xml:",chardata"`
+}
+
+// renderAnimeListXML renders m in the anime-lists XML format, treating
+// each arc as a season and the original series as AniDB season 1. Arcs
+// with no parseable episode references are left out.
+func renderAnimeListXML(m model.AnimeMapping) ([]byte, error) {
+	entry := animeListEntry{
+		AniDBID:           m.AniDBID,
+		TVDBID:            m.TVDBID,
+		DefaultTVDBSeason: "a",
+		Name:              "One Pace",
+	}
+
+	for _, arc := range m.Arcs {
+		targets := make(map[int][]int)
+		for _, ep := range arc.Episodes {
+			for _, n := range ep.AnimeEpisodes {
+				targets[n] = append(targets[n], ep.Episode)
+			}
+		}
+		if len(targets) == 0 {
+			continue
+		}
+
+		sources := make([]int, 0, len(targets))
+		for n := range targets {
+			sources = append(sources, n)
+		}
+		sort.Ints(sources)
+
+		var b strings.Builder
+		b.WriteByte(';')
+		for _, n := range sources {
+			b.WriteString(strconv.Itoa(n))
+			b.WriteByte('-')
+			for i, ep := range targets[n] {
+				if i > 0 {
+					b.WriteByte('+')
+				}
+				b.WriteString(strconv.Itoa(ep))
+			}
+			b.WriteByte(';')
+		}
+
+		entry.Mappings = append(entry.Mappings, animeListMapping{
+			AniDBSeason: 1,
+			TVDBSeason:  arc.Arc,
+			Pairs:       b.String(),
+		})
+	}
+
+	out, err := xml.MarshalIndent(animeList{Anime: []animeListEntry{entry}}, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), out...), nil
+}
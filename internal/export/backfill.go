@@ -0,0 +1,197 @@
+package export
+
+import (
+	"encoding/json"
+	"slices"
+	"sort"
+
+	"metadata-service/internal/fetch"
+	"metadata-service/internal/model"
+	"metadata-service/internal/parse"
+	"metadata-service/internal/util"
+)
+
+// BackfillLinks fills in the view, magnet and .torrent links of episode
+// archive entries under outDir that lack any of them, which a normal
+// export only does when a CRC is first archived. Nyaa search links
+// ("nyaa.si/?q=<infohash>") count as missing and are replaced by the
+// torrent's view page once it's found. Sources are tried in order until
+// the entry is complete: the releases archive by CRC32, then by infohash,
+// the other half of a Nyaa view/.torrent pair, a Nyaa search by CRC32 and
+// by infohash (skipped when nyaa is nil), and finally a bare magnet from
+// the infohash. Only missing fields are filled. Rewrites episodes.json,
+// episodes-current.json and reports/links.json.
+func BackfillLinks(outDir string, nyaa *fetch.NyaaCache) (model.LinkBackfillReport, error) {
+	report := model.LinkBackfillReport{Entries: []model.LinkBackfillEntry{}}
+	archive, err := LoadEpisodesArchive(outDir + "/episodes.json")
+	if err != nil {
+		return report, err
+	}
+	releases := ReleasesArchive{}
+	if util.FileExists(outDir + "/releases.json") {
+		if releases, err = LoadReleasesArchive(outDir + "/releases.json"); err != nil {
+			return report, err
+		}
+	}
+	releasesByCRC := make(map[string]model.Release)
+	for _, r := range releases {
+		if r.CRC32 == "" {
+			continue
+		}
+		// Prefer the release that's still live when a CRC was re-listed.
+		if existing, ok := releasesByCRC[r.CRC32]; ok && existing.Status != model.ReleaseOutdated {
+			continue
+		}
+		releasesByCRC[r.CRC32] = r
+	}
+
+	crcs := make([]string, 0, len(archive))
+	for crc := range archive {
+		crcs = append(crcs, crc)
+	}
+	sort.Strings(crcs)
+
+	for _, crc := range crcs {
+		entry := archive[crc]
+		if linksComplete(entry.File) {
+			continue
+		}
+		report.Incomplete++
+		result := backfillFile(&entry.File, releasesByCRC, releases, nyaa)
+		result.CRC32, result.EpisodeID, result.Title = crc, entry.EpisodeID, entry.Title
+		if len(result.Missing) == 0 {
+			report.Completed++
+		}
+		report.Entries = append(report.Entries, result)
+		archive[crc] = entry
+	}
+
+	if _, err := writeDataFiles(outDir, "episodes", archive); err != nil {
+		return report, err
+	}
+	trackers, err := LoadTrackers(outDir + "/trackers.json")
+	if err != nil {
+		return report, err
+	}
+	if _, err := writeDataFiles(outDir, "episodes-current", buildCurrentEpisodes(archive, trackers)); err != nil {
+		return report, err
+	}
+
+	reportsDir := outDir + "/reports"
+	if err := util.EnsureDir(reportsDir); err != nil {
+		return report, err
+	}
+	reportJSON, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return report, err
+	}
+	_, err = writeFileIfChanged(reportsDir+"/links.json", reportJSON)
+	return report, err
+}
+
+// linksComplete reports whether a file has all three download links, with
+// a real view page rather than a search as its URL.
+func linksComplete(f model.EpisodeFile) bool {
+	_, search := parse.NyaaSearch(f.URL)
+	return f.URL != "" && !search && f.MagnetURI != "" && f.TorrentURL != ""
+}
+
+// backfillFile fills f's missing links (see BackfillLinks), reporting what
+// it filled, from where, and what's left.
+func backfillFile(f *model.EpisodeFile, releasesByCRC map[string]model.Release, releases ReleasesArchive, nyaa *fetch.NyaaCache) model.LinkBackfillEntry {
+	var result model.LinkBackfillEntry
+
+	// A search URL is only kept if nothing better turns up.
+	infoHash, search := parse.NyaaSearch(f.URL)
+	if search {
+		result.SearchURL, f.URL = f.URL, ""
+	}
+	if f.Magnet != nil && f.Magnet.InfoHash != "" {
+		infoHash = f.Magnet.InfoHash
+	} else if f.ReleaseInfoHash != "" && infoHash == "" {
+		infoHash = f.ReleaseInfoHash
+	}
+
+	set := func(field *string, name, value, source string) {
+		if *field != "" || value == "" {
+			return
+		}
+		*field = value
+		result.Filled = append(result.Filled, name)
+		if !slices.Contains(result.Sources, source) {
+			result.Sources = append(result.Sources, source)
+		}
+	}
+	setMagnet := func(uri, source string) {
+		if f.MagnetURI != "" || uri == "" {
+			return
+		}
+		set(&f.MagnetURI, "magnet_uri", parse.CompactMagnet(uri), source)
+		f.Magnet = parse.Magnet(f.MagnetURI)
+		if infoHash == "" && f.Magnet != nil {
+			infoHash = f.Magnet.InfoHash
+		}
+	}
+	fromRelease := func(r model.Release, source string) {
+		set(&f.URL, "url", r.NyaaURL, source)
+		setMagnet(r.MagnetURI, source)
+		set(&f.TorrentURL, "torrent_url", r.TorrentURL, source)
+		if f.ReleaseInfoHash == "" {
+			f.ReleaseInfoHash = r.InfoHash
+		}
+		if f.Resolution == "" {
+			f.Resolution = r.Resolution
+		}
+	}
+	fromNyaa := func(t *model.NyaaTorrent, source string) {
+		if t == nil {
+			return
+		}
+		set(&f.URL, "url", t.ViewURL, source)
+		setMagnet(t.MagnetURI, source)
+		set(&f.TorrentURL, "torrent_url", t.TorrentURL, source)
+		if f.Resolution == "" {
+			f.Resolution = parse.FilenameResolution(t.Title)
+		}
+	}
+	fromNyaaLink := func() {
+		view, torrent := parse.NyaaLinks(f.URL)
+		if view == "" {
+			view, torrent = parse.NyaaLinks(f.TorrentURL)
+		}
+		set(&f.URL, "url", view, model.LinkSourceNyaaLink)
+		set(&f.TorrentURL, "torrent_url", torrent, model.LinkSourceNyaaLink)
+	}
+	complete := func() bool { return linksComplete(*f) }
+
+	if r, ok := releasesByCRC[f.CRC32]; ok {
+		fromRelease(r, model.LinkSourceRelease)
+	}
+	if r, ok := releases[infoHash]; ok && !complete() {
+		fromRelease(r, model.LinkSourceReleaseInfoHash)
+	}
+	fromNyaaLink()
+	if nyaa != nil && !complete() {
+		fromNyaa(nyaa.Lookup(f.CRC32), model.LinkSourceNyaa)
+	}
+	if nyaa != nil && !complete() && infoHash != "" {
+		fromNyaa(nyaa.LookupInfoHash(infoHash), model.LinkSourceNyaaInfoHash)
+	}
+	if infoHash != "" {
+		setMagnet("magnet:?xt=urn:btih:"+infoHash, model.LinkSourceInfoHash)
+	}
+
+	if f.URL == "" {
+		f.URL = result.SearchURL
+	}
+	if _, search := parse.NyaaSearch(f.URL); f.URL == "" || search {
+		result.Missing = append(result.Missing, "url")
+	}
+	if f.MagnetURI == "" {
+		result.Missing = append(result.Missing, "magnet_uri")
+	}
+	if f.TorrentURL == "" {
+		result.Missing = append(result.Missing, "torrent_url")
+	}
+	return result
+}
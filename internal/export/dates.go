@@ -0,0 +1,58 @@
+package export
+
+import (
+	"sort"
+
+	"metadata-service/internal/model"
+	"metadata-service/internal/parse"
+)
+
+// reparseDate retries an unknown date stored by an older run with the
+// sheet parser, e.g. a format parse.Date has since learned. Reports
+// whether d changed.
+func reparseDate(d *model.Date) bool {
+	if d.Known() || d.Raw == "" {
+		return false
+	}
+	if parsed, ok := parse.Date(d.Raw); ok && parsed.Known() {
+		*d = parsed
+		return true
+	}
+	return false
+}
+
+// unparsedDate reports whether d holds text that is neither a date nor a
+// "not released yet" placeholder.
+func unparsedDate(d model.Date) bool {
+	return !d.Known() && !parse.IsDatePlaceholder(d.Raw)
+}
+
+// buildDateReport lists every date in this run's arcs and the archives
+// that couldn't be parsed, sorted for stable output.
+func buildDateReport(arcs []model.Arc, archive EpisodesArchive, releases ReleasesArchive) []model.UnparsedDate {
+	report := []model.UnparsedDate{}
+	for _, arc := range arcs {
+		for _, ep := range arc.Episodes {
+			if unparsedDate(ep.Released) {
+				report = append(report, model.UnparsedDate{Source: "episode", ID: ep.ID, Title: ep.Title, Value: ep.Released.Raw})
+			}
+		}
+	}
+	for crc, entry := range archive {
+		if unparsedDate(entry.Released) {
+			report = append(report, model.UnparsedDate{Source: "archive", ID: crc, Title: entry.Title, Value: entry.Released.Raw})
+		}
+	}
+	for hash, r := range releases {
+		if !r.PublishedAt.Known() && r.PublishedAt.Raw != "" {
+			report = append(report, model.UnparsedDate{Source: "release", ID: hash, Title: r.Title, Value: r.PublishedAt.Raw})
+		}
+	}
+	sort.Slice(report, func(a, b int) bool {
+		if report[a].Source != report[b].Source {
+			return report[a].Source < report[b].Source
+		}
+		return report[a].ID < report[b].ID
+	})
+	return report
+}
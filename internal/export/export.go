@@ -2,14 +2,20 @@ package export
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io/fs"
 	"os"
+	"sort"
 	"time"
 
 	"gopkg.in/yaml.v3"
 
+	"metadata-service/internal/coverage"
 	"metadata-service/internal/fetch"
+	"metadata-service/internal/index"
 	"metadata-service/internal/model"
+	"metadata-service/internal/parse"
 	"metadata-service/internal/util"
 )
 
@@ -21,6 +27,14 @@ type EpisodesArchive map[string]model.EpisodeArchiveEntry
 // { "<InfoHash>": Release }
 type ReleasesArchive map[string]model.Release
 
+// NyaaCacheFile is where Nyaa search results are cached between runs,
+// under the data directory. See fetch.NyaaCache.
+const NyaaCacheFile = "cache/nyaa.json"
+
+// ReleasesFeedFile is where the releases feed's ETag and Last-Modified are
+// kept between runs, under the data directory. See fetch.FetchReleases.
+const ReleasesFeedFile = "cache/releases-feed.json"
+
 func ExportMetadata(arcs []model.Arc, releases []model.Release, outDir string) error {
 
 	// Ensure output directory exists
@@ -29,13 +43,20 @@ func ExportMetadata(arcs []model.Arc, releases []model.Release, outDir string) e
 	}
 	metadataChanged := false
 
-	// Index releases by CRC32 so the episode archive can be enriched with
-	// magnet/torrent links without a per-CRC Nyaa search.
-	releasesByCRC := make(map[string]model.Release, len(releases))
-	for _, r := range releases {
-		if r.CRC32 != "" {
-			releasesByCRC[r.CRC32] = r
-		}
+	// Magnets are stored compact (infohash + filename); their trackers go
+	// to the tracker registry instead (see 5c) and its active trackers are
+	// added back on output.
+	seenTrackers := trackerSet{}
+	releases = append([]model.Release(nil), releases...)
+	for i := range releases {
+		seenTrackers.compactRelease(&releases[i])
+	}
+
+	// The Nyaa searches for files the releases feed doesn't cover (see
+	// releasesByCRC below) are cached between runs.
+	nyaa, err := fetch.LoadNyaaCache(outDir + "/" + NyaaCacheFile)
+	if err != nil {
+		return err
 	}
 	// ========================================================
 	// 1) EXPORT ARCS (modern structure)
@@ -65,6 +86,119 @@ func ExportMetadata(arcs []model.Arc, releases []model.Release, outDir string) e
 		metadataChanged = true
 	}
 
+	// ========================================================
+	// 1b) EXPORT TVSHOW (template + live facts)
+	// ========================================================
+
+	show, err := buildTVShow(arcs)
+	if err != nil {
+		return err
+	}
+	changed, err := writeDataFiles(outDir, "tvshow", show)
+	if err != nil {
+		return err
+	}
+	if changed {
+		metadataChanged = true
+	}
+
+	// ========================================================
+	// 1c) EXPORT ANIME CROSS-REFERENCE (anime-lists style)
+	// ========================================================
+
+	animeMapping := buildAnimeMapping(arcs)
+	animeMappingJSON, err := json.MarshalIndent(animeMapping, "", "  ")
+	if err != nil {
+		return err
+	}
+	changed, err = writeFileIfChanged(outDir+"/anime-list.json", animeMappingJSON)
+	if err != nil {
+		return err
+	}
+	if changed {
+		metadataChanged = true
+	}
+	// anime-lists consumers skip entries without a real TVDB ID, so the XML
+	// waits for one to be configured, and one written before is removed.
+	if animeMapping.TVDBID == "" {
+		fmt.Println("Warning: no TVDB ID configured (config.OnePaceTVDBID, export -tvdb); skipping anime-list.xml")
+		if err := os.Remove(outDir + "/anime-list.xml"); err == nil {
+			metadataChanged = true
+		} else if !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+	} else {
+		animeListXML, err := renderAnimeListXML(animeMapping)
+		if err != nil {
+			return err
+		}
+		changed, err = writeFileIfChanged(outDir+"/anime-list.xml", animeListXML)
+		if err != nil {
+			return err
+		}
+		if changed {
+			metadataChanged = true
+		}
+	}
+
+	// ========================================================
+	// 1d) EXPORT REVERSE INDEXES (chapter / anime ep -> episodes)
+	// ========================================================
+
+	chapterIndex, animeEpisodeIndex := index.Build(arcs)
+	indexDir := outDir + "/index"
+	if err := util.EnsureDir(indexDir); err != nil {
+		return err
+	}
+	for name, ix := range map[string]index.Index{
+		index.ChaptersFile:      chapterIndex,
+		index.AnimeEpisodesFile: animeEpisodeIndex,
+	} {
+		ixJSON, err := json.MarshalIndent(ix, "", "  ")
+		if err != nil {
+			return err
+		}
+		changed, err := writeFileIfChanged(indexDir+"/"+name, ixJSON)
+		if err != nil {
+			return err
+		}
+		if changed {
+			metadataChanged = true
+		}
+	}
+
+	// ========================================================
+	// 1e) EXPORT CHAPTER COVERAGE REPORT
+	// ========================================================
+
+	reportsDir := outDir + "/reports"
+	if err := util.EnsureDir(reportsDir); err != nil {
+		return err
+	}
+	coverageJSON, err := json.MarshalIndent(coverage.Analyze(arcs), "", "  ")
+	if err != nil {
+		return err
+	}
+	changed, err = writeFileIfChanged(reportsDir+"/coverage.json", coverageJSON)
+	if err != nil {
+		return err
+	}
+	if changed {
+		metadataChanged = true
+	}
+
+	// ========================================================
+	// 1f) EXPORT SAGAS
+	// ========================================================
+
+	changed, err = writeDataFiles(outDir, "sagas", BuildSagas(arcs))
+	if err != nil {
+		return err
+	}
+	if changed {
+		metadataChanged = true
+	}
+
 	// ========================================================
 	// 2) LOAD EXISTING EPISODE ARCHIVE (append-only)
 	// ========================================================
@@ -76,6 +210,105 @@ func ExportMetadata(arcs []model.Arc, releases []model.Release, outDir string) e
 		raw, _ := os.ReadFile(archivePath)
 		_ = json.Unmarshal(raw, &archive)
 	}
+	for crc, entry := range archive {
+		changed := seenTrackers.compactFile(&entry.File)
+		if reparseDate(&entry.Released) {
+			changed = true
+		}
+		if changed {
+			archive[crc] = entry
+			metadataChanged = true
+		}
+	}
+
+	// ========================================================
+	// 2b) LOAD + MERGE RELEASES ARCHIVE (append-only)
+	// ========================================================
+	// Loaded ahead of the episode archive's IsCurrent pass (3d), which
+	// needs every release's feed status — including when this run's feed
+	// fetch failed.
+
+	releasesArchive := ReleasesArchive{}
+	releasesPath := outDir + "/releases.json"
+
+	if util.FileExists(releasesPath) {
+		raw, _ := os.ReadFile(releasesPath)
+		_ = json.Unmarshal(raw, &releasesArchive)
+	}
+	for hash, r := range releasesArchive {
+		if seenTrackers.compactRelease(&r) {
+			releasesArchive[hash] = r
+			metadataChanged = true
+		}
+	}
+
+	for _, r := range releases {
+		if r.InfoHash == "" {
+			continue
+		}
+		existing, exists := releasesArchive[r.InfoHash]
+		if !exists {
+			releasesArchive[r.InfoHash] = r
+			metadataChanged = true
+			continue
+		}
+		// The one field the feed does change after publishing: a release
+		// is recategorized as "outdated" once it's superseded.
+		if existing.Variant != r.Variant {
+			existing.Variant = r.Variant
+			existing.NormalizedVariant, existing.Status = r.NormalizedVariant, r.Status
+			releasesArchive[r.InfoHash] = existing
+			metadataChanged = true
+		}
+	}
+
+	// Releases archived before magnets were parsed get their Magnet now,
+	// ones archived before statuses existed get their Status, ones
+	// archived before changelogs were classified get their entries, and
+	// ones archived before references were parsed get their refs.
+	for hash, r := range releasesArchive {
+		changed := false
+		if r.MangaChapterRefs == nil && r.MangaChapters != "" {
+			r.MangaChapterRefs = parse.References(r.MangaChapters, model.SegmentMangaChapter)
+			changed = true
+		}
+		if r.AnimeEpisodeRefs == nil && r.AnimeEpisodes != "" {
+			r.AnimeEpisodeRefs = parse.References(r.AnimeEpisodes, model.SegmentAnimeEpisode)
+			changed = true
+		}
+		if r.Magnet == nil && r.MagnetURI != "" {
+			if r.Magnet = parse.Magnet(r.MagnetURI); r.Magnet != nil {
+				changed = true
+			}
+		}
+		if r.Status == "" {
+			r.NormalizedVariant, r.Status = parse.ReleaseVariant(r.Variant, r.Title)
+			changed = true
+		}
+		if r.ChangelogEntries == nil && len(r.Changelog) > 0 {
+			r.ChangelogEntries = parse.Changelog(r.Changelog)
+			changed = true
+		}
+		if changed {
+			releasesArchive[hash] = r
+			metadataChanged = true
+		}
+	}
+
+	// Index releases by CRC32 so the episode archive can be enriched with
+	// magnet/torrent links without a per-CRC Nyaa search. The whole archive
+	// is indexed, since the feed may only have handed over what's new.
+	releasesByCRC := make(map[string]model.Release, len(releasesArchive))
+	for _, r := range releasesArchive {
+		if r.CRC32 == "" {
+			continue
+		}
+		// Prefer the release that's still live when a CRC was re-listed.
+		if existing, ok := releasesByCRC[r.CRC32]; ok && existing.Status != model.ReleaseOutdated {
+			continue
+		}
+		releasesByCRC[r.CRC32] = r
+	}
 
 	// ========================================================
 	// 3) MERGE NEW EPISODES — ALWAYS APPEND, NEVER REMOVE
@@ -92,19 +325,23 @@ func ExportMetadata(arcs []model.Arc, releases []model.Release, outDir string) e
 					if _, exists := archive[key]; !exists {
 
 						file := *ep.Files.Normal
-						enrichFileFromRelease(&file, releasesByCRC)
+						enrichFileFromRelease(&file, releasesByCRC, nyaa)
 
 						archive[key] = model.EpisodeArchiveEntry{
-							ArcID:       arc.ID,
-							EpisodeID:   ep.ID,
-							Arc:         ep.Arc,
-							Episode:     ep.Episode,
-							Title:       ep.Title,
-							Description: ep.Description,
-							Chapters:    ep.Chapters,
-							AnimeEps:    ep.AnimeEps,
-							Released:    ep.Released,
-							File:        file,
+							ArcID:            arc.ID,
+							EpisodeID:        ep.ID,
+							Arc:              ep.Arc,
+							Episode:          ep.Episode,
+							Title:            ep.Title,
+							Description:      ep.Description,
+							Chapters:         ep.Chapters,
+							AnimeEps:         ep.AnimeEps,
+							ChapterRefs:      ep.ChapterRefs,
+							AnimeEpisodeRefs: ep.AnimeEpisodeRefs,
+							Released:         ep.Released,
+							Titles:           ep.Titles,
+							Descriptions:     ep.Descriptions,
+							File:             file,
 						}
 						metadataChanged = true
 					}
@@ -119,19 +356,23 @@ func ExportMetadata(arcs []model.Arc, releases []model.Release, outDir string) e
 					if _, exists := archive[key]; !exists {
 
 						file := *ep.Files.Extended
-						enrichFileFromRelease(&file, releasesByCRC)
+						enrichFileFromRelease(&file, releasesByCRC, nyaa)
 
 						archive[key] = model.EpisodeArchiveEntry{
-							ArcID:       arc.ID,
-							EpisodeID:   ep.ID,
-							Arc:         ep.Arc,
-							Episode:     ep.Episode,
-							Title:       ep.Title,
-							Description: ep.Description,
-							Chapters:    ep.Chapters,
-							AnimeEps:    ep.AnimeEps,
-							Released:    ep.Released,
-							File:        file,
+							ArcID:            arc.ID,
+							EpisodeID:        ep.ID,
+							Arc:              ep.Arc,
+							Episode:          ep.Episode,
+							Title:            ep.Title,
+							Description:      ep.Description,
+							Chapters:         ep.Chapters,
+							AnimeEps:         ep.AnimeEps,
+							ChapterRefs:      ep.ChapterRefs,
+							AnimeEpisodeRefs: ep.AnimeEpisodeRefs,
+							Released:         ep.Released,
+							Titles:           ep.Titles,
+							Descriptions:     ep.Descriptions,
+							File:             file,
 						}
 						metadataChanged = true
 					}
@@ -147,30 +388,46 @@ func ExportMetadata(arcs []model.Arc, releases []model.Release, outDir string) e
 	// so entries created before this feature won't have magnet_uri/torrent_url
 	// from the "new episode" path above. Fill in only what's missing —
 	// additive, never overwrites an entry's existing data.
+	// Magnets stored before they were parsed get their Magnet here too, and
+	// entries stored before references were parsed get their refs.
 	for crc, entry := range archive {
-		if entry.File.MagnetURI != "" && entry.File.TorrentURL != "" {
-			continue
-		}
-		release, ok := releasesByCRC[crc]
-		if !ok {
-			continue
-		}
 		changed := false
-		if entry.File.MagnetURI == "" && release.MagnetURI != "" {
-			entry.File.MagnetURI = release.MagnetURI
+		if entry.ChapterRefs == nil && entry.Chapters != "" {
+			entry.ChapterRefs = parse.References(entry.Chapters, model.SegmentMangaChapter)
 			changed = true
 		}
-		if entry.File.TorrentURL == "" && release.TorrentURL != "" {
-			entry.File.TorrentURL = release.TorrentURL
+		if entry.AnimeEpisodeRefs == nil && entry.AnimeEps != "" {
+			entry.AnimeEpisodeRefs = parse.References(entry.AnimeEps, model.SegmentAnimeEpisode)
 			changed = true
 		}
-		if entry.File.URL == "" && release.NyaaURL != "" {
-			entry.File.URL = release.NyaaURL
-			changed = true
+		if entry.File.Magnet == nil && entry.File.MagnetURI != "" {
+			entry.File.Magnet = parse.Magnet(entry.File.MagnetURI)
+			if entry.File.Magnet != nil {
+				changed = true
+			}
 		}
-		if entry.File.ReleaseInfoHash == "" && release.InfoHash != "" {
-			entry.File.ReleaseInfoHash = release.InfoHash
-			changed = true
+		if release, ok := releasesByCRC[crc]; ok {
+			if entry.File.MagnetURI == "" && release.MagnetURI != "" {
+				entry.File.MagnetURI = release.MagnetURI
+				entry.File.Magnet = releaseMagnet(release)
+				changed = true
+			}
+			if entry.File.TorrentURL == "" && release.TorrentURL != "" {
+				entry.File.TorrentURL = release.TorrentURL
+				changed = true
+			}
+			if entry.File.URL == "" && release.NyaaURL != "" {
+				entry.File.URL = release.NyaaURL
+				changed = true
+			}
+			if entry.File.ReleaseInfoHash == "" && release.InfoHash != "" {
+				entry.File.ReleaseInfoHash = release.InfoHash
+				changed = true
+			}
+			if entry.File.Resolution == "" && release.Resolution != "" {
+				entry.File.Resolution = release.Resolution
+				changed = true
+			}
 		}
 		if changed {
 			archive[crc] = entry
@@ -213,12 +470,30 @@ func ExportMetadata(arcs []model.Arc, releases []model.Release, outDir string) e
 	// 3d) COMPUTE IS_CURRENT PER (EPISODE, VARIANT)
 	// ========================================================
 	// Episodes get re-released under new CRC32s over time; mark the entry
-	// with the newest Released date (ISO YYYY-MM-DD, so lexicographic
-	// comparison is chronological) as current within its group, so a
+	// with the newest Released date as current within its group, so a
 	// consumer can find "the" download link without scanning every
 	// historical CRC itself. Groups by EpisodeID when known, falling back
 	// to the raw (Arc, Episode) numbers for any entry the backfill above
-	// couldn't resolve.
+	// couldn't resolve. An unparseable date never wins (see
+	// model.Date.After); those are listed in reports/dates.json. The feed
+	// has the final say over sheet dates: a file whose release the feed
+	// marks outdated only stays current if every file in its group is
+	// outdated. The newest file of each other resolution is current too,
+	// as an alternate, but only if it's at least as new as the group's
+	// current file and not outdated, so a superseded encode doesn't stay
+	// current just because nothing replaced it at its own resolution. An
+	// entry whose resolution was never recorded counts as its arc's primary
+	// (highest) resolution.
+	defaultResolution := make(map[string]string)
+	for _, arc := range arcs {
+		defaultResolution[arc.ID] = highestResolution(arc.ResolutionList)
+	}
+	resolutionOf := func(entry model.EpisodeArchiveEntry) string {
+		if entry.File.Resolution != "" {
+			return entry.File.Resolution
+		}
+		return defaultResolution[entry.ArcID]
+	}
 	type versionKey struct {
 		Episode string
 		Variant string
@@ -232,23 +507,72 @@ func ExportMetadata(arcs []model.Arc, releases []model.Release, outDir string) e
 		k := versionKey{Episode: epKey, Variant: entry.File.Version}
 		groups[k] = append(groups[k], crc)
 	}
+	outdated := outdatedCRCs(releasesArchive)
+	// newer reports whether crc should be picked over other.
+	newer := func(crc, other string) bool {
+		if outdated[crc] != outdated[other] {
+			return outdated[other]
+		}
+		return archive[crc].Released.After(archive[other].Released)
+	}
 	for _, crcs := range groups {
+		sort.Strings(crcs) // so ties go the same way every run
 		latest := crcs[0]
-		for _, crc := range crcs[1:] {
-			if archive[crc].Released > archive[latest].Released {
+		newestByResolution := make(map[string]string)
+		for _, crc := range crcs {
+			if newer(crc, latest) {
 				latest = crc
 			}
+			resolution := resolutionOf(archive[crc])
+			if best, ok := newestByResolution[resolution]; !ok || newer(crc, best) {
+				newestByResolution[resolution] = crc
+			}
+		}
+		current := map[string]bool{latest: true}
+		for resolution, crc := range newestByResolution {
+			if resolution != resolutionOf(archive[latest]) && !outdated[crc] &&
+				!archive[latest].Released.After(archive[crc].Released) {
+				current[crc] = true
+			}
 		}
 		for _, crc := range crcs {
-			want := crc == latest
-			if entry := archive[crc]; entry.IsCurrent != want {
-				entry.IsCurrent = want
+			if entry := archive[crc]; entry.IsCurrent != current[crc] {
+				entry.IsCurrent = current[crc]
 				archive[crc] = entry
 				metadataChanged = true
 			}
 		}
 	}
 
+	// ========================================================
+	// 3e) BACKFILL LOCALIZED TITLES/DESCRIPTIONS
+	// ========================================================
+	// Translations land in the descriptions sheet after an episode's
+	// files are archived, so add any language an entry doesn't have yet
+	// from this run's episodes. Additive like 3b: a language an entry
+	// already has is never overwritten.
+	episodesByID := make(map[string]model.Episode)
+	for _, arc := range arcs {
+		for _, ep := range arc.Episodes {
+			if ep.ID != "" {
+				episodesByID[ep.ID] = ep
+			}
+		}
+	}
+	for crc, entry := range archive {
+		ep, ok := episodesByID[entry.EpisodeID]
+		if !ok {
+			continue
+		}
+		titles, titlesChanged := addMissingLanguages(entry.Titles, ep.Titles)
+		descriptions, descriptionsChanged := addMissingLanguages(entry.Descriptions, ep.Descriptions)
+		if titlesChanged || descriptionsChanged {
+			entry.Titles, entry.Descriptions = titles, descriptions
+			archive[crc] = entry
+			metadataChanged = true
+		}
+	}
+
 	// ========================================================
 	// 4) WRITE EPISODE ARCHIVE (legacy format)
 	// ========================================================
@@ -279,36 +603,19 @@ func ExportMetadata(arcs []model.Arc, releases []model.Release, outDir string) e
 	// 4b) BUILD + WRITE DERIVED "CURRENT" VIEW
 	// ========================================================
 	// One entry per episode, keyed by EpisodeID, holding only the archive
-	// entries marked IsCurrent per variant — see model.CurrentEpisode.
-	currentEpisodes := make(map[string]model.CurrentEpisode)
-	for _, entry := range archive {
-		if !entry.IsCurrent {
-			continue
-		}
-		epKey := entry.EpisodeID
-		if epKey == "" {
-			epKey = fmt.Sprintf("%d-%d", entry.Arc, entry.Episode)
-		}
-
-		ce := currentEpisodes[epKey]
-		ce.ArcID = entry.ArcID
-		ce.EpisodeID = entry.EpisodeID
-		ce.Arc = entry.Arc
-		ce.Episode = entry.Episode
-		ce.Title = entry.Title
-		ce.Description = entry.Description
-		ce.Chapters = entry.Chapters
-		ce.AnimeEps = entry.AnimeEps
-		ce.Released = entry.Released
-
-		file := entry.File
-		if file.Version == "extended" {
-			ce.Files.Extended = &file
-		} else {
-			ce.Files.Normal = &file
+	// entries marked IsCurrent per variant — see model.CurrentEpisode. With
+	// several resolutions current, the highest fills Normal/Extended and
+	// the rest go to Files.Alternates. Magnets get their trackers back from
+	// the tracker registry, including the trackers seen this run.
+	trackersPath := outDir + "/trackers.json"
+	var previousTrackers []model.Tracker
+	if util.FileExists(trackersPath) {
+		if err := loadJSON(trackersPath, &previousTrackers); err != nil {
+			return err
 		}
-		currentEpisodes[epKey] = ce
 	}
+	trackerRegistry := buildTrackerRegistry(previousTrackers, seenTrackers)
+	currentEpisodes := buildCurrentEpisodes(archive, activeTrackers(trackerRegistry))
 
 	currentPath := outDir + "/episodes-current.json"
 	currentJSON, err := json.MarshalIndent(currentEpisodes, "", "  ")
@@ -332,26 +639,22 @@ func ExportMetadata(arcs []model.Arc, releases []model.Release, outDir string) e
 	}
 
 	// ========================================================
-	// 5) LOAD + MERGE + WRITE RELEASES ARCHIVE (append-only)
+	// 5) MATCH + WRITE RELEASES ARCHIVE
 	// ========================================================
 
-	releasesArchive := ReleasesArchive{}
-	releasesPath := outDir + "/releases.json"
-
-	if util.FileExists(releasesPath) {
-		raw, _ := os.ReadFile(releasesPath)
-		_ = json.Unmarshal(raw, &releasesArchive)
-	}
-
-	for _, r := range releases {
-		if r.InfoHash == "" {
-			continue
-		}
-		if _, exists := releasesArchive[r.InfoHash]; !exists {
-			releasesArchive[r.InfoHash] = r
+	// Join every release (new or archived) to its arc/episode. Additive
+	// like 3b/3c: IDs already recorded are never overwritten, so a release
+	// keeps its match even after its arc leaves the sheet.
+	matcher := newReleaseMatcher(arcs, archive)
+	for hash, r := range releasesArchive {
+		if matcher.match(&r) {
+			releasesArchive[hash] = r
 			metadataChanged = true
 		}
 	}
+	if linkSuperseded(releasesArchive) {
+		metadataChanged = true
+	}
 
 	releasesJSON, err := json.MarshalIndent(releasesArchive, "", "  ")
 	if err != nil {
@@ -373,6 +676,68 @@ func ExportMetadata(arcs []model.Arc, releases []model.Release, outDir string) e
 		}
 	}
 
+	// ========================================================
+	// 5b) WRITE RELEASE MATCH REPORT
+	// ========================================================
+
+	matchJSON, err := json.MarshalIndent(buildReleaseMatchReport(releasesArchive), "", "  ")
+	if err != nil {
+		return err
+	}
+	if _, err := writeFileIfChanged(reportsDir+"/release-matches.json", matchJSON); err != nil {
+		return err
+	}
+
+	// ========================================================
+	// 5c) WRITE TRACKER REGISTRY
+	// ========================================================
+
+	trackersJSON, err := json.MarshalIndent(trackerRegistry, "", "  ")
+	if err != nil {
+		return err
+	}
+	changed, err = writeFileIfChanged(trackersPath, trackersJSON)
+	if err != nil {
+		return err
+	}
+	if changed {
+		metadataChanged = true
+	}
+
+	// ========================================================
+	// 5d) WRITE UNPARSEABLE DATE REPORT
+	// ========================================================
+
+	dateReport := buildDateReport(arcs, archive, releasesArchive)
+	if len(dateReport) > 0 {
+		fmt.Printf("Warning: %d unparseable dates, see reports/dates.json\n", len(dateReport))
+	}
+	datesJSON, err := json.MarshalIndent(dateReport, "", "  ")
+	if err != nil {
+		return err
+	}
+	if _, err := writeFileIfChanged(reportsDir+"/dates.json", datesJSON); err != nil {
+		return err
+	}
+
+	// ========================================================
+	// 5e) WRITE EPISODE HISTORY
+	// ========================================================
+
+	changed, err = writeDataFiles(outDir, "episode-history", BuildEpisodeHistory(arcs, archive, releasesArchive))
+	if err != nil {
+		return err
+	}
+	if changed {
+		metadataChanged = true
+	}
+
+	// Keep this run's Nyaa lookups for the next one. Not metadata, so it
+	// doesn't count as a change.
+	if err := nyaa.Save(); err != nil {
+		return err
+	}
+
 	// ========================================================
 	// 6) WRITE STATUS FILE
 	// ========================================================
@@ -397,19 +762,178 @@ func ExportMetadata(arcs []model.Arc, releases []model.Release, outDir string) e
 	return nil
 }
 
-// enrichFileFromRelease fills in an episode file's download links, preferring
+// buildCurrentEpisodes derives the "current" view (episodes-current.json)
+// from the archive, with trackers added to its magnets; see step 4b of
+// ExportMetadata.
+func buildCurrentEpisodes(archive EpisodesArchive, trackers []string) map[string]model.CurrentEpisode {
+	currentEpisodes := make(map[string]model.CurrentEpisode)
+	for _, entry := range archive {
+		if !entry.IsCurrent {
+			continue
+		}
+		epKey := entry.EpisodeID
+		if epKey == "" {
+			epKey = fmt.Sprintf("%d-%d", entry.Arc, entry.Episode)
+		}
+
+		ce := currentEpisodes[epKey]
+		ce.ArcID = entry.ArcID
+		ce.EpisodeID = entry.EpisodeID
+		ce.Arc = entry.Arc
+		ce.Episode = entry.Episode
+		ce.Title = entry.Title
+		ce.Description = entry.Description
+		ce.Chapters = entry.Chapters
+		ce.AnimeEps = entry.AnimeEps
+		ce.ChapterRefs = entry.ChapterRefs
+		ce.AnimeEpisodeRefs = entry.AnimeEpisodeRefs
+		ce.Released = entry.Released
+		ce.Titles = entry.Titles
+		ce.Descriptions = entry.Descriptions
+
+		file := FileWithTrackers(entry.File, trackers)
+		slot := &ce.Files.Normal
+		if file.Version == "extended" {
+			slot = &ce.Files.Extended
+		}
+		switch {
+		case *slot == nil:
+			*slot = &file
+		case parse.ResolutionHeight(file.Resolution) > parse.ResolutionHeight((*slot).Resolution):
+			ce.Files.Alternates = append(ce.Files.Alternates, **slot)
+			*slot = &file
+		default:
+			ce.Files.Alternates = append(ce.Files.Alternates, file)
+		}
+		currentEpisodes[epKey] = ce
+	}
+	for _, ce := range currentEpisodes {
+		sort.Slice(ce.Files.Alternates, func(a, b int) bool {
+			fa, fb := ce.Files.Alternates[a], ce.Files.Alternates[b]
+			if fa.Version != fb.Version {
+				return fa.Version > fb.Version // "normal" before "extended"
+			}
+			return parse.ResolutionHeight(fa.Resolution) > parse.ResolutionHeight(fb.Resolution)
+		})
+	}
+	return currentEpisodes
+}
+
+// enrichFileFromRelease fills in an episode file's download links (and its
+// resolution, which the release filename states exactly), preferring
 // the onepace.net releases feed (exact CRC match, no network round-trip)
-// over the Nyaa RSS search fallback used when a CRC isn't in the feed.
-func enrichFileFromRelease(file *model.EpisodeFile, releasesByCRC map[string]model.Release) {
+// over the (cached) Nyaa RSS search used when a CRC isn't in the feed.
+func enrichFileFromRelease(file *model.EpisodeFile, releasesByCRC map[string]model.Release, nyaa *fetch.NyaaCache) {
 	if release, ok := releasesByCRC[file.CRC32]; ok {
+		if release.Resolution != "" {
+			file.Resolution = release.Resolution
+		}
 		file.URL = release.NyaaURL
 		file.MagnetURI = release.MagnetURI
+		file.Magnet = releaseMagnet(release)
 		file.TorrentURL = release.TorrentURL
 		file.ReleaseInfoHash = release.InfoHash
 		return
 	}
 
+	if file.URL != "" && file.MagnetURI != "" {
+		return
+	}
+	t := nyaa.Lookup(file.CRC32)
+	if t == nil {
+		return
+	}
 	if file.URL == "" {
-		file.URL = fetch.ResolveNyaaURL(file.CRC32)
+		file.URL = t.ViewURL
+	}
+	if file.MagnetURI == "" && t.MagnetURI != "" {
+		file.MagnetURI = t.MagnetURI
+		file.Magnet = parse.Magnet(t.MagnetURI)
+	}
+	if file.TorrentURL == "" {
+		file.TorrentURL = t.TorrentURL
+	}
+	if r := parse.FilenameResolution(t.Title); r != "" {
+		file.Resolution = r
+	}
+}
+
+// addMissingLanguages returns have plus every language of from that have
+// lacks, and whether anything was added. have is copied, not modified.
+func addMissingLanguages(have, from model.Localized) (model.Localized, bool) {
+	var out model.Localized
+	for tag, text := range from {
+		if _, ok := have[tag]; ok || text == "" {
+			continue
+		}
+		if out == nil {
+			out = make(model.Localized, len(have)+len(from))
+			for t, v := range have {
+				out[t] = v
+			}
+		}
+		out[tag] = text
+	}
+	if out == nil {
+		return have, false
+	}
+	return out, true
+}
+
+// releaseMagnet returns the release's parsed magnet, parsing MagnetURI for
+// releases that predate the Magnet field.
+func releaseMagnet(release model.Release) *model.Magnet {
+	if release.Magnet != nil {
+		return release.Magnet
+	}
+	return parse.Magnet(release.MagnetURI)
+}
+
+// writeDataFiles writes v as both <name>.json and <name>.yml under outDir,
+// skipping files whose content is unchanged. Reports whether either file
+// was rewritten.
+func writeDataFiles(outDir, name string, v any) (bool, error) {
+	jsonData, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return false, err
+	}
+	jsonChanged, err := writeFileIfChanged(outDir+"/"+name+".json", jsonData)
+	if err != nil {
+		return false, err
+	}
+
+	yamlData, err := yaml.Marshal(v)
+	if err != nil {
+		return false, err
+	}
+	yamlChanged, err := writeFileIfChanged(outDir+"/"+name+".yml", yamlData)
+	if err != nil {
+		return false, err
+	}
+
+	return jsonChanged || yamlChanged, nil
+}
+
+// writeFileIfChanged writes data to path unless the file already holds the
+// same content. Reports whether it wrote.
+func writeFileIfChanged(path string, data []byte) (bool, error) {
+	if util.FileUnchanged(path, data) {
+		return false, nil
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// highestResolution returns the highest of a list of normalized
+// resolutions, or "" for an empty list.
+func highestResolution(resolutions []string) string {
+	best := ""
+	for _, r := range resolutions {
+		if parse.ResolutionHeight(r) > parse.ResolutionHeight(best) {
+			best = r
+		}
 	}
+	return best
 }
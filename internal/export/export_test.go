@@ -4,9 +4,14 @@ import (
 	"encoding/json"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
 	"testing"
+	"time"
 
+	"metadata-service/internal/config"
 	"metadata-service/internal/model"
+	"metadata-service/internal/parse"
 )
 
 // TestExportMetadata_IsCurrentAndBackfill exercises the parts of the export
@@ -29,7 +34,7 @@ func TestExportMetadata_IsCurrentAndBackfill(t *testing.T) {
 				Version: "normal",
 				CRC32:   "AAAAAAAA",
 			},
-			Released: "2024-01-01",
+			Released: model.NewDate(2024, 1, 1),
 		},
 		"CCCCCCCC": model.EpisodeArchiveEntry{
 			ArcID:     "arc1",
@@ -41,7 +46,7 @@ func TestExportMetadata_IsCurrentAndBackfill(t *testing.T) {
 				Version: "normal",
 				CRC32:   "CCCCCCCC",
 			},
-			Released:  "2023-01-01",
+			Released:  model.NewDate(2023, 1, 1),
 			IsCurrent: true,
 		},
 	}
@@ -66,7 +71,7 @@ func TestExportMetadata_IsCurrentAndBackfill(t *testing.T) {
 					Arc:      1,
 					Episode:  1,
 					Title:    "New Title",
-					Released: "2025-01-01",
+					Released: model.NewDate(2025, 1, 1),
 					Files: model.EpisodeFileVariants{
 						Normal: &model.EpisodeFile{Version: "normal", CRC32: "BBBBBBBB"},
 					},
@@ -76,7 +81,7 @@ func TestExportMetadata_IsCurrentAndBackfill(t *testing.T) {
 					Arc:      1,
 					Episode:  2,
 					Title:    "Unrelated",
-					Released: "2023-01-01",
+					Released: model.NewDate(2023, 1, 1),
 					Files: model.EpisodeFileVariants{
 						Normal: &model.EpisodeFile{Version: "normal", CRC32: "CCCCCCCC"},
 					},
@@ -146,3 +151,773 @@ func TestExportMetadata_IsCurrentAndBackfill(t *testing.T) {
 		t.Errorf("arc1-002 current file = %+v, want CRC32 CCCCCCCC", ep2.Files.Normal)
 	}
 }
+
+func TestBuildTVShow(t *testing.T) {
+	arcs := []model.Arc{
+		{
+			ID: "arc1",
+			Episodes: []model.Episode{
+				{
+					Released: model.NewDate(2024, 5, 1),
+					Files: model.EpisodeFileVariants{
+						Normal:   &model.EpisodeFile{LengthSeconds: 1000},
+						Extended: &model.EpisodeFile{LengthSeconds: 1500},
+					},
+				},
+				{
+					Released: model.NewDate(2025, 2, 3),
+					Files: model.EpisodeFileVariants{
+						Extended: &model.EpisodeFile{LengthSeconds: 200},
+					},
+				},
+			},
+		},
+		{ID: "arc2", Status: "TBR", Episodes: []model.Episode{{Released: model.NewDate(2023, 1, 1)}}},
+	}
+
+	show, err := buildTVShow(arcs)
+	if err != nil {
+		t.Fatalf("buildTVShow: %v", err)
+	}
+	if show.Title != "One Pace" || len(show.Genre) == 0 || show.Plot == "" {
+		t.Errorf("template fields not loaded: %+v", show)
+	}
+	if show.Status != "Ended" {
+		t.Errorf("Status = %q, want Ended (no WIP arcs)", show.Status)
+	}
+	if show.ArcCount != 2 || show.EpisodeCount != 3 {
+		t.Errorf("ArcCount/EpisodeCount = %d/%d, want 2/3", show.ArcCount, show.EpisodeCount)
+	}
+	if show.LatestRelease != "2025-02-03" {
+		t.Errorf("LatestRelease = %q, want 2025-02-03", show.LatestRelease)
+	}
+	if show.TotalRuntimeSeconds != 1200 {
+		t.Errorf("TotalRuntimeSeconds = %d, want 1200 (normal cut preferred)", show.TotalRuntimeSeconds)
+	}
+
+	arcs[1].Status = "WIP"
+	show, err = buildTVShow(arcs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if show.Status != "Continuing" {
+		t.Errorf("Status = %q, want Continuing with a WIP arc", show.Status)
+	}
+}
+
+func TestBuildSagas(t *testing.T) {
+	arcs := []model.Arc{
+		{ID: "rd", SagaID: "east-blue", Saga: "East Blue"},
+		{ID: "ot", SagaID: "east-blue", Saga: "East Blue"},
+		{ID: "new"},
+		{ID: "rm", SagaID: "alabasta", Saga: "Alabasta"},
+	}
+	sagas := BuildSagas(arcs)
+	if len(sagas) != 2 {
+		t.Fatalf("got %d sagas, want 2: %+v", len(sagas), sagas)
+	}
+	if s := sagas[0]; s.Number != 1 || s.Title != "East Blue" || strings.Join(s.ArcIDs, ",") != "rd,ot" {
+		t.Errorf("sagas[0] = %+v", s)
+	}
+	if s := sagas[1]; s.Number != 2 || s.ID != "alabasta" || strings.Join(s.ArcIDs, ",") != "rm" {
+		t.Errorf("sagas[1] = %+v", s)
+	}
+}
+
+func TestAnimeMapping(t *testing.T) {
+	episode := func(id string, n int, animeEps string) model.Episode {
+		return model.Episode{ID: id, Episode: n, AnimeEps: animeEps,
+			AnimeEpisodeRefs: parse.References(animeEps, model.SegmentAnimeEpisode)}
+	}
+	arcs := []model.Arc{
+		{
+			ID: "lg", Arc: 12, Title: "Little Garden", AnimeEpisodes: "70 - 78",
+			Episodes: []model.Episode{episode("lg-005", 5, "Ep. 77-78")},
+		},
+		{
+			ID: "di", Arc: 13, Title: "Drum Island",
+			Episodes: []model.Episode{
+				episode("di-001", 1, "Ep. 78-79"),
+				episode("di-002", 2, "Ep. 79"),
+				episode("di-003", 3, "Episode of Sabo"),
+				episode("di-004", 4, "Episode of East Blue, Ep. 80 (Intro)"),
+			},
+		},
+		{ID: "tbr", Arc: 14, Title: "Unreleased"},
+	}
+
+	m := buildAnimeMapping(arcs)
+	if m.AniDBID == 0 || len(m.Arcs) != 3 {
+		t.Fatalf("mapping = %+v", m)
+	}
+	if got := m.Arcs[1].Episodes[0].AnimeEpisodes; len(got) != 2 || got[0] != 78 || got[1] != 79 {
+		t.Errorf("di-001 anime episodes = %v, want [78 79]", got)
+	}
+	if got := m.Arcs[1].Episodes[2].AnimeEpisodes; got != nil {
+		t.Errorf("a special should map to nothing, got %v", got)
+	}
+	if got := m.Arcs[1].Episodes[3].AnimeEpisodes; len(got) != 1 || got[0] != 80 {
+		t.Errorf("di-004 anime episodes = %v, want [80] next to the special", got)
+	}
+
+	m.TVDBID = "12345"
+	raw, err := renderAnimeListXML(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := string(raw)
+	for _, want := range []string{
+		`<anime anidbid="69" tvdbid="12345" defaulttvdbseason="a">`,
+		`<mapping anidbseason="1" tvdbseason="12">;77-5;78-5;</mapping>`,
+		`<mapping anidbseason="1" tvdbseason="13">;78-1;79-1+2;80-4;</mapping>`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("XML missing %s:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, `tvdbseason="14"`) {
+		t.Error("arc with no episodes should not get a mapping")
+	}
+}
+
+// TestExportMetadata_AnimeListNeedsTVDBID checks that anime-list.xml is only
+// written once a TVDB ID is configured, and removed again without one.
+func TestExportMetadata_AnimeListNeedsTVDBID(t *testing.T) {
+	dir := t.TempDir()
+	xmlPath := filepath.Join(dir, "anime-list.xml")
+	defer func(id string) { config.OnePaceTVDBID = id }(config.OnePaceTVDBID)
+
+	config.OnePaceTVDBID = "12345"
+	if err := ExportMetadata(nil, nil, dir); err != nil {
+		t.Fatalf("ExportMetadata: %v", err)
+	}
+	if _, err := os.Stat(xmlPath); err != nil {
+		t.Errorf("anime-list.xml not written with a TVDB ID: %v", err)
+	}
+
+	config.OnePaceTVDBID = ""
+	if err := ExportMetadata(nil, nil, dir); err != nil {
+		t.Fatalf("ExportMetadata: %v", err)
+	}
+	if _, err := os.Stat(xmlPath); err == nil {
+		t.Error("anime-list.xml kept without a TVDB ID")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "anime-list.json")); err != nil {
+		t.Errorf("anime-list.json: %v", err)
+	}
+}
+
+// TestExportMetadata_CurrentPerResolution checks that each resolution of an
+// episode keeps its own current file, and that entries recorded before
+// resolutions were tracked are treated as the arc's primary resolution.
+func TestExportMetadata_CurrentPerResolution(t *testing.T) {
+	dir := t.TempDir()
+
+	seed := EpisodesArchive{
+		// Pre-resolution entry: counts as the arc's 1080p.
+		"AAAAAAAA": {ArcID: "arc1", EpisodeID: "arc1-001", Arc: 1, Episode: 1, Released: model.NewDate(2020, 1, 1),
+			File: model.EpisodeFile{Version: "normal", CRC32: "AAAAAAAA"}},
+		// A 720p encode released alongside the 1080p below: current as its alternate.
+		"CCCCCCCC": {ArcID: "arc1", EpisodeID: "arc1-001", Arc: 1, Episode: 1, Released: model.NewDate(2022, 1, 1),
+			File: model.EpisodeFile{Version: "normal", CRC32: "CCCCCCCC", Resolution: "720p"}},
+	}
+	seedJSON, err := json.Marshal(seed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "episodes.json"), seedJSON, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	arcs := []model.Arc{{
+		ID: "arc1", Arc: 1, ResolutionList: []string{"720p", "1080p"},
+		Episodes: []model.Episode{{
+			ID: "arc1-001", Arc: 1, Episode: 1, Released: model.NewDate(2022, 1, 1),
+			Files: model.EpisodeFileVariants{
+				Normal: &model.EpisodeFile{Version: "normal", CRC32: "BBBBBBBB", Resolution: "1080p"},
+			},
+		}},
+	}}
+
+	if err := ExportMetadata(arcs, nil, dir); err != nil {
+		t.Fatalf("ExportMetadata: %v", err)
+	}
+
+	archive, err := LoadEpisodesArchive(filepath.Join(dir, "episodes.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for crc, want := range map[string]bool{"AAAAAAAA": false, "BBBBBBBB": true, "CCCCCCCC": true} {
+		if got := archive[crc].IsCurrent; got != want {
+			t.Errorf("%s IsCurrent = %v, want %v", crc, got, want)
+		}
+	}
+
+	current, err := LoadCurrentEpisodes(filepath.Join(dir, "episodes-current.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := current["arc1-001"].Files
+	if files.Normal == nil || files.Normal.CRC32 != "BBBBBBBB" {
+		t.Errorf("Normal = %+v, want the 1080p BBBBBBBB", files.Normal)
+	}
+	if len(files.Alternates) != 1 || files.Alternates[0].CRC32 != "CCCCCCCC" {
+		t.Errorf("Alternates = %+v, want the 720p CCCCCCCC", files.Alternates)
+	}
+}
+
+// TestExportMetadata_SupersededResolution checks that a lower resolution
+// file doesn't stay current once a newer file at another resolution
+// replaces it, nor does one whose release the feed marks outdated.
+func TestExportMetadata_SupersededResolution(t *testing.T) {
+	dir := t.TempDir()
+
+	seed := EpisodesArchive{
+		"AAAAAAAA": {ArcID: "arc1", EpisodeID: "arc1-001", Released: model.NewDate(2019, 1, 1), IsCurrent: true,
+			File: model.EpisodeFile{Version: "normal", CRC32: "AAAAAAAA", Resolution: "720p"}},
+		"BBBBBBBB": {ArcID: "arc1", EpisodeID: "arc1-001", Released: model.NewDate(2024, 1, 1),
+			File: model.EpisodeFile{Version: "normal", CRC32: "BBBBBBBB", Resolution: "1080p"}},
+		// As new as BBBBBBBB, but pulled from the feed.
+		"CCCCCCCC": {ArcID: "arc1", EpisodeID: "arc1-001", Released: model.NewDate(2024, 1, 1),
+			File: model.EpisodeFile{Version: "normal", CRC32: "CCCCCCCC", Resolution: "480p"}},
+	}
+	seedJSON, err := json.Marshal(seed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "episodes.json"), seedJSON, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	releases := []model.Release{{InfoHash: "old", Title: "Romance Dawn 01", Variant: "outdated", CRC32: "CCCCCCCC"}}
+	for i := range releases {
+		releases[i].NormalizedVariant, releases[i].Status = parse.ReleaseVariant(releases[i].Variant, releases[i].Title)
+	}
+
+	if err := ExportMetadata(nil, releases, dir); err != nil {
+		t.Fatalf("ExportMetadata: %v", err)
+	}
+
+	archive, err := LoadEpisodesArchive(filepath.Join(dir, "episodes.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for crc, want := range map[string]bool{"AAAAAAAA": false, "BBBBBBBB": true, "CCCCCCCC": false} {
+		if got := archive[crc].IsCurrent; got != want {
+			t.Errorf("%s IsCurrent = %v, want %v", crc, got, want)
+		}
+	}
+
+	current, err := LoadCurrentEpisodes(filepath.Join(dir, "episodes-current.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := current["arc1-001"].Files
+	if files.Normal == nil || files.Normal.CRC32 != "BBBBBBBB" || len(files.Alternates) != 0 {
+		t.Errorf("Files = %+v, want only the 1080p BBBBBBBB", files)
+	}
+}
+
+// TestExportMetadata_ReferencesBackfill checks that archive entries and
+// releases stored before references were parsed get them on the next run.
+func TestExportMetadata_ReferencesBackfill(t *testing.T) {
+	dir := t.TempDir()
+
+	episodes := EpisodesArchive{
+		"AAAAAAAA": {EpisodeID: "arc1-001", Chapters: "1-3", AnimeEps: "Ep. 1",
+			File: model.EpisodeFile{Version: "normal", CRC32: "AAAAAAAA"}},
+		// A stored magnet that doesn't parse mustn't stop the refs being saved.
+		"BBBBBBBB": {EpisodeID: "arc1-002", Chapters: "4-5",
+			File: model.EpisodeFile{Version: "normal", CRC32: "BBBBBBBB", MagnetURI: "https://example.com/not-a-magnet"}},
+	}
+	releases := ReleasesArchive{
+		"aaa": {InfoHash: "aaa", Title: "Romance Dawn 01", Variant: "regular", CRC32: "AAAAAAAA",
+			MangaChapters: "1-3", AnimeEpisodes: "Ep. 1"},
+	}
+	for name, v := range map[string]any{"episodes.json": episodes, "releases.json": releases} {
+		raw, err := json.Marshal(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, name), raw, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := ExportMetadata(nil, nil, dir); err != nil {
+		t.Fatalf("ExportMetadata: %v", err)
+	}
+
+	wantChapters := parse.References("1-3", model.SegmentMangaChapter)
+	wantAnime := parse.References("Ep. 1", model.SegmentAnimeEpisode)
+	same := func(got, want *model.References) bool {
+		a, _ := json.Marshal(got)
+		b, _ := json.Marshal(want)
+		return got != nil && string(a) == string(b)
+	}
+
+	archive, err := LoadEpisodesArchive(filepath.Join(dir, "episodes.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e := archive["AAAAAAAA"]; !same(e.ChapterRefs, wantChapters) || !same(e.AnimeEpisodeRefs, wantAnime) {
+		t.Errorf("archive entry refs = %+v / %+v", e.ChapterRefs, e.AnimeEpisodeRefs)
+	}
+	if e := archive["BBBBBBBB"]; !same(e.ChapterRefs, parse.References("4-5", model.SegmentMangaChapter)) {
+		t.Errorf("entry with an unparseable magnet: chapter refs = %+v", e.ChapterRefs)
+	}
+	current, err := LoadCurrentEpisodes(filepath.Join(dir, "episodes-current.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ce := current["arc1-001"]; !same(ce.ChapterRefs, wantChapters) {
+		t.Errorf("current episode chapter refs = %+v", ce.ChapterRefs)
+	}
+
+	archived, err := LoadReleasesArchive(filepath.Join(dir, "releases.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r := archived["aaa"]; !same(r.MangaChapterRefs, wantChapters) || !same(r.AnimeEpisodeRefs, wantAnime) {
+		t.Errorf("release refs = %+v / %+v", r.MangaChapterRefs, r.AnimeEpisodeRefs)
+	}
+}
+
+// TestExportMetadata_OutdatedRelease checks that the feed's "outdated"
+// status overrides the sheet's dates when picking the current file, and
+// that the outdated release is linked to its successor.
+func TestExportMetadata_OutdatedRelease(t *testing.T) {
+	dir := t.TempDir()
+
+	// BBBBBBBB has the newer sheet date, but the feed has since pulled it.
+	seed := EpisodesArchive{
+		"AAAAAAAA": {EpisodeID: "arc1-001", Released: model.NewDate(2020, 1, 1),
+			File: model.EpisodeFile{Version: "normal", CRC32: "AAAAAAAA"}},
+		"BBBBBBBB": {EpisodeID: "arc1-001", Released: model.NewDate(2022, 1, 1),
+			File: model.EpisodeFile{Version: "normal", CRC32: "BBBBBBBB"}},
+	}
+	seedJSON, err := json.Marshal(seed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "episodes.json"), seedJSON, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	published := func(year int) model.Timestamp {
+		return model.Timestamp{Time: time.Date(year, 1, 1, 12, 0, 0, 0, time.UTC)}
+	}
+	releases := []model.Release{
+		{InfoHash: "old", Title: "Romance Dawn 01", Variant: "outdated", CRC32: "BBBBBBBB", PublishedAt: published(2022)},
+		{InfoHash: "new", Title: "Romance Dawn 01", Variant: "regular", CRC32: "AAAAAAAA", PublishedAt: published(2023)},
+	}
+	for i := range releases {
+		releases[i].NormalizedVariant, releases[i].Status = parse.ReleaseVariant(releases[i].Variant, releases[i].Title)
+	}
+
+	if err := ExportMetadata(nil, releases, dir); err != nil {
+		t.Fatalf("ExportMetadata: %v", err)
+	}
+
+	archive, err := LoadEpisodesArchive(filepath.Join(dir, "episodes.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !archive["AAAAAAAA"].IsCurrent || archive["BBBBBBBB"].IsCurrent {
+		t.Errorf("IsCurrent: AAAAAAAA=%v BBBBBBBB=%v, want the non-outdated AAAAAAAA",
+			archive["AAAAAAAA"].IsCurrent, archive["BBBBBBBB"].IsCurrent)
+	}
+
+	archived, err := LoadReleasesArchive(filepath.Join(dir, "releases.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := archived["old"]; got.Status != model.ReleaseOutdated || got.SupersededBy != "new" {
+		t.Errorf("old release = status %q superseded by %q, want outdated by \"new\"", got.Status, got.SupersededBy)
+	}
+	if got := archived["new"].SupersededBy; got != "" {
+		t.Errorf("active release superseded by %q", got)
+	}
+}
+
+func TestExportMetadata_LocalizedBackfill(t *testing.T) {
+	dir := t.TempDir()
+
+	seed := EpisodesArchive{
+		"AAAAAAAA": {EpisodeID: "arc1-001", Title: "Old", Titles: model.Localized{"en": "Old"},
+			File: model.EpisodeFile{Version: "normal", CRC32: "AAAAAAAA"}},
+	}
+	seedJSON, err := json.Marshal(seed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "episodes.json"), seedJSON, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	arcs := []model.Arc{{ID: "arc1", Arc: 1, Episodes: []model.Episode{{
+		ID: "arc1-001", Arc: 1, Episode: 1, Title: "New",
+		Titles:       model.Localized{"en": "New", "es": "Nuevo"},
+		Descriptions: model.Localized{"es": "Descripción"},
+		Files:        model.EpisodeFileVariants{Normal: &model.EpisodeFile{Version: "normal", CRC32: "AAAAAAAA"}},
+	}}}}
+	if err := ExportMetadata(arcs, nil, dir); err != nil {
+		t.Fatalf("ExportMetadata: %v", err)
+	}
+
+	archive, err := LoadEpisodesArchive(filepath.Join(dir, "episodes.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	entry := archive["AAAAAAAA"]
+	if entry.Titles["en"] != "Old" || entry.Titles["es"] != "Nuevo" || entry.Descriptions["es"] != "Descripción" {
+		t.Errorf("localized backfill = %v / %v, want es added and en kept", entry.Titles, entry.Descriptions)
+	}
+
+	current, err := LoadCurrentEpisodes(filepath.Join(dir, "episodes-current.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := current["arc1-001"].Titles["es"]; got != "Nuevo" {
+		t.Errorf("current es title = %q, want Nuevo", got)
+	}
+}
+
+func TestBuildEpisodeHistory(t *testing.T) {
+	arcs := []model.Arc{{ID: "rd", Arc: 1, Title: "Romance Dawn", Episodes: []model.Episode{
+		{ID: "rd-001", Arc: 1, Episode: 1, Title: "Romance Dawn, the Dawn of an Adventure"},
+	}}}
+	archive := EpisodesArchive{
+		"BBBBBBBB": {EpisodeID: "rd-001", Released: model.NewDate(2023, 5, 1), IsCurrent: true,
+			File: model.EpisodeFile{Version: "normal", CRC32: "BBBBBBBB"}},
+		"AAAAAAAA": {EpisodeID: "rd-001", Released: model.NewDate(2021, 1, 1),
+			File: model.EpisodeFile{Version: "normal", CRC32: "AAAAAAAA"}},
+	}
+	published := model.Timestamp{Time: time.Date(2022, 3, 1, 0, 0, 0, 0, time.UTC)}
+	releases := ReleasesArchive{
+		"new": {InfoHash: "new", CRC32: "BBBBBBBB", Status: model.ReleaseActive,
+			Changelog: []string{"Added English dub.", "03:02 - Microcut"}},
+		// Known only from the feed; sorts between the two by publish time.
+		"mid": {InfoHash: "mid", CRC32: "CCCCCCCC", EpisodeID: "rd-001", NormalizedVariant: "normal",
+			Status: model.ReleaseOutdated, PublishedAt: published},
+	}
+
+	history := BuildEpisodeHistory(arcs, archive, releases)
+	h, ok := history["rd-001"]
+	if !ok {
+		t.Fatal("no history for rd-001")
+	}
+	if h.ArcID != "rd" || h.Title != "Romance Dawn, the Dawn of an Adventure" {
+		t.Errorf("history header = %+v", h)
+	}
+	var crcs []string
+	for _, v := range h.Versions {
+		crcs = append(crcs, v.CRC32)
+	}
+	if strings.Join(crcs, ",") != "AAAAAAAA,CCCCCCCC,BBBBBBBB" {
+		t.Fatalf("versions = %v, want oldest first", crcs)
+	}
+	latest := h.Versions[2]
+	if latest.InfoHash != "new" || !latest.IsCurrent || len(latest.Changelog) != 2 ||
+		strings.Join(latest.Changes, ",") != "audio,video" {
+		t.Errorf("latest version = %+v", latest)
+	}
+}
+
+func TestReleaseMatcher(t *testing.T) {
+	arcs := []model.Arc{
+		{ID: "rd", Title: "Romance Dawn", Episodes: []model.Episode{
+			{ID: "rd-001", Episode: 1},
+			{ID: "rd-002", Episode: 2},
+		}},
+		{ID: "gm", Title: "Gaimon", Episodes: []model.Episode{{ID: "gm-001", Episode: 1}}},
+		{ID: "sh", Title: "The Adventures of the Straw Hats", Episodes: []model.Episode{{ID: "sh-000", Episode: 0}}},
+	}
+	archive := EpisodesArchive{
+		"AAAAAAAA": {ArcID: "rd", EpisodeID: "rd-002", Arc: 1, Episode: 2},
+	}
+	m := newReleaseMatcher(arcs, archive)
+
+	two := 2
+	cases := []struct {
+		release          model.Release
+		arcID, episodeID string
+		by               string
+	}{
+		// CRC wins over a (deliberately wrong) title.
+		{model.Release{Title: "Romance Dawn 01", CRC32: "AAAAAAAA"}, "rd", "rd-002", "crc32"},
+		{model.Release{Title: "romance dawn 01 Extended Cut", CRC32: "BBBBBBBB"}, "rd", "rd-001", "title"},
+		{model.Release{Title: "Gaimon"}, "gm", "gm-001", "title"},
+		{model.Release{Title: "Romance Dawn"}, "rd", "", "title"},
+		{model.Release{Title: "If You Could Go Anywhere... The Adventures of the Straw Hats 00"}, "sh", "sh-000", "title"},
+		{model.Release{Title: "Specials 04"}, "", "", ""},
+		// The filename is tried when the title doesn't name a known arc.
+		{model.Release{Title: "Romance Dawn Redux 02", Magnet: &model.Magnet{
+			File: &model.ReleaseFilename{Arc: "Romance Dawn", Episode: &two},
+		}}, "rd", "rd-002", "filename"},
+	}
+	for _, c := range cases {
+		r := c.release
+		m.match(&r)
+		if r.ArcID != c.arcID || r.EpisodeID != c.episodeID || r.MatchedBy != c.by {
+			t.Errorf("match(%q) = %q/%q by %q, want %q/%q by %q",
+				c.release.Title, r.ArcID, r.EpisodeID, r.MatchedBy, c.arcID, c.episodeID, c.by)
+		}
+	}
+
+	// IDs already on a release are kept.
+	r := model.Release{Title: "Romance Dawn 01", ArcID: "old", EpisodeID: "old-001", MatchedBy: "title"}
+	if m.match(&r) || r.EpisodeID != "old-001" {
+		t.Errorf("match overwrote existing IDs: %+v", r)
+	}
+
+	report := buildReleaseMatchReport(ReleasesArchive{
+		"h1": {InfoHash: "h1", Title: "Romance Dawn 01", EpisodeID: "rd-001", MatchedBy: "title"},
+		"h2": {InfoHash: "h2", Title: "Romance Dawn", ArcID: "rd"},
+		"h3": {InfoHash: "h3", Title: "Specials 04"},
+	})
+	if report.ByTitle != 1 || len(report.ArcOnly) != 1 || len(report.Unmatched) != 1 || report.Unmatched[0].InfoHash != "h3" {
+		t.Errorf("unexpected report: %+v", report)
+	}
+}
+
+func TestTrackerRegistry(t *testing.T) {
+	seen := trackerSet{}
+	r := model.Release{
+		MagnetURI: "magnet:?xt=urn:btih:aaaa&dn=A.mkv&tr=udp%3A%2F%2Fdead.example%3A80",
+		Magnet:    &model.Magnet{InfoHash: "aaaa", Trackers: []string{"udp://dead.example:80"}},
+	}
+	if !seen.compactRelease(&r) {
+		t.Fatal("compactRelease reported no change")
+	}
+	if r.MagnetURI != "magnet:?xt=urn:btih:aaaa&dn=A.mkv" || len(r.Magnet.Trackers) != 0 {
+		t.Errorf("not compacted: %+v", r)
+	}
+	// Already compact: nothing to do, and the tracker isn't recounted.
+	if seen.compactRelease(&r) {
+		t.Error("compactRelease changed an already compact release")
+	}
+
+	prevDenylist := config.TrackerDenylist
+	config.TrackerDenylist = []string{"dead.example"}
+	defer func() { config.TrackerDenylist = prevDenylist }()
+
+	previous := []model.Tracker{{URL: "udp://gone.example:80", Releases: 7}}
+	registry := buildTrackerRegistry(previous, seen)
+	byURL := make(map[string]model.Tracker)
+	for _, tr := range registry {
+		byURL[tr.URL] = tr
+	}
+	if got := byURL["udp://dead.example:80"]; got.Releases != 1 || got.Active {
+		t.Errorf("denylisted tracker = %+v", got)
+	}
+	if got := byURL["udp://gone.example:80"]; got.Releases != 7 || !got.Active {
+		t.Errorf("previous tracker dropped, recounted or inactive: %+v", got)
+	}
+	for _, url := range config.MagnetTrackers {
+		if !byURL[url].Active {
+			t.Errorf("configured tracker %s missing or inactive", url)
+		}
+	}
+
+	// Output magnets get the registry's trackers, not just the configured
+	// ones, and never a denylisted one.
+	path := filepath.Join(t.TempDir(), "trackers.json")
+	raw, err := json.Marshal(registry)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, raw, 0644); err != nil {
+		t.Fatal(err)
+	}
+	trackers, err := LoadTrackers(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if trackers[0] != "udp://gone.example:80" || !slices.Contains(trackers, config.MagnetTrackers[0]) ||
+		slices.Contains(trackers, "udp://dead.example:80") {
+		t.Errorf("LoadTrackers = %v", trackers)
+	}
+	if trackers, err := LoadTrackers(filepath.Join(t.TempDir(), "missing.json")); err != nil || !slices.Equal(trackers, activeTrackers(buildTrackerRegistry(nil, nil))) {
+		t.Errorf("LoadTrackers without a registry = %v, %v; want the configured trackers", trackers, err)
+	}
+
+	full := ReleaseWithTrackers(r, trackers)
+	if !strings.Contains(full.MagnetURI, "&tr=udp%3A%2F%2Fgone.example%3A80") || len(full.Magnet.Trackers) != len(trackers) {
+		t.Errorf("trackers not added back: %+v", full)
+	}
+	if len(r.Magnet.Trackers) != 0 {
+		t.Error("ReleaseWithTrackers modified its argument's Magnet")
+	}
+}
+
+// TestFileWithTrackersDropsDeadTrackers checks that the dead trackers old
+// releases announce, which the registry records, stay out of output magnets.
+func TestFileWithTrackersDropsDeadTrackers(t *testing.T) {
+	seen := trackerSet{}
+	f := model.EpisodeFile{
+		MagnetURI: "magnet:?xt=urn:btih:aaaa&dn=A.mkv" +
+			"&tr=http%3A%2F%2Fnyaa.tracker.wf%3A7777%2Fannounce" +
+			"&tr=udp%3A%2F%2Ftracker.coppersurfer.tk%3A6969" +
+			"&tr=udp%3A%2F%2Fopentracker.i2p.rocks%3A6969%2Fannounce",
+	}
+	seen.compactFile(&f)
+	registry := buildTrackerRegistry(nil, seen)
+
+	full := FileWithTrackers(f, activeTrackers(registry))
+	for _, dead := range []string{"coppersurfer", "i2p.rocks"} {
+		if strings.Contains(full.MagnetURI, dead) {
+			t.Errorf("output magnet announces %s: %s", dead, full.MagnetURI)
+		}
+	}
+	if !strings.Contains(full.MagnetURI, "nyaa.tracker.wf") {
+		t.Errorf("output magnet lost a live tracker: %s", full.MagnetURI)
+	}
+	for _, tr := range registry {
+		if strings.Contains(tr.URL, "coppersurfer") && tr.Active {
+			t.Errorf("dead tracker recorded as active: %+v", tr)
+		}
+	}
+}
+
+func TestCheckSheetLayouts(t *testing.T) {
+	dir := t.TempDir()
+	layout := func(sheet string, columns int, header ...string) model.SheetLayout {
+		return model.SheetLayout{
+			Sheet: sheet, Title: "tab " + sheet, Header: header, Columns: columns,
+			Fingerprint: parse.LayoutFingerprint(header, columns),
+		}
+	}
+
+	// First run: nothing to compare against.
+	first := model.GuideReport{Layouts: []model.SheetLayout{
+		layout("0", 4, "No.", "Arcs", "Manga Chapters", "Anime Episodes"),
+		layout("11", 5, "", "Name", "Chapters", "Released", "CRC32"),
+		layout("22", 3, "", "Name", "CRC32"),
+	}}
+	if err := CheckSheetLayouts(&first, dir, config.DriftFail); err != nil || len(first.Drift) != 0 {
+		t.Fatalf("first run: err = %v, drift = %+v", err, first.Drift)
+	}
+
+	// Arc list: a column inserted and one renamed. Tab 11: two columns
+	// swapped. Tab 22 wasn't read this time.
+	second := model.GuideReport{Layouts: []model.SheetLayout{
+		layout("0", 5, "No.", "Saga", "Arcs", "Chapters", "Anime Episodes"),
+		layout("11", 5, "", "Name", "Chapters", "CRC32", "Released"),
+	}}
+	err := CheckSheetLayouts(&second, dir, config.DriftFail)
+	if err == nil {
+		t.Fatal("drift with the fail policy: want an error")
+	}
+	var got []string
+	for _, d := range second.Drift {
+		got = append(got, d.Sheet+" "+d.Kind+" "+d.Header)
+	}
+	want := []string{
+		"0 added Saga",
+		"0 renamed Chapters",
+		"0 columns ",
+		"11 moved CRC32",
+	}
+	if strings.Join(got, "; ") != strings.Join(want, "; ") {
+		t.Errorf("drift = %q, want %q", got, want)
+	}
+	if d := second.Drift[1]; d.Previous != "Manga Chapters" || d.Column != 3 {
+		t.Errorf("rename = %+v", d)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "reports", "guide.json")); err != nil {
+		t.Errorf("fail policy didn't write the report: %v", err)
+	}
+
+	// Failing leaves the baseline alone, so the same drift is reported
+	// again; continuing records it.
+	if err := CheckSheetLayouts(&second, dir, config.DriftContinue); err != nil || len(second.Drift) != 4 {
+		t.Fatalf("continue: err = %v, drift = %+v", err, second.Drift)
+	}
+	third := model.GuideReport{Layouts: second.Layouts}
+	if err := CheckSheetLayouts(&third, dir, config.DriftFail); err != nil || len(third.Drift) != 0 {
+		t.Fatalf("after accepting: err = %v, drift = %+v", err, third.Drift)
+	}
+
+	var saved []model.SheetLayout
+	if err := loadJSON(filepath.Join(dir, LayoutsFile), &saved); err != nil {
+		t.Fatal(err)
+	}
+	if len(saved) != 3 || saved[2].Sheet != "22" {
+		t.Errorf("saved layouts = %+v, want the unread tab kept", saved)
+	}
+}
+
+func TestBackfillLinks(t *testing.T) {
+	dir := t.TempDir()
+	const (
+		knownHash   = "00d22c441e261ae3005e32736f2154b1156f5c48"
+		unknownHash = "1111111111111111111111111111111111111111"
+	)
+	archive := EpisodesArchive{
+		// Only a search link, for a torrent the releases archive has.
+		"AAAAAAAA": {EpisodeID: "a-001", File: model.EpisodeFile{CRC32: "AAAAAAAA", URL: "https://nyaa.si/?q=" + knownHash}},
+		// A view page but nothing else.
+		"BBBBBBBB": {EpisodeID: "a-002", File: model.EpisodeFile{CRC32: "BBBBBBBB", URL: "https://nyaa.si/view/42"}},
+		// A search for a torrent nobody knows.
+		"CCCCCCCC": {EpisodeID: "a-003", File: model.EpisodeFile{CRC32: "CCCCCCCC", URL: "https://nyaa.si/?q=" + unknownHash}},
+		// Already complete.
+		"DDDDDDDD": {EpisodeID: "a-004", File: model.EpisodeFile{
+			CRC32: "DDDDDDDD", URL: "https://nyaa.si/view/7", TorrentURL: "https://nyaa.si/download/7.torrent",
+			MagnetURI: "magnet:?xt=urn:btih:" + strings.Repeat("d", 40),
+		}},
+	}
+	releases := ReleasesArchive{
+		knownHash: {
+			InfoHash: knownHash, Resolution: "1080p",
+			NyaaURL: "https://nyaa.si/view/1583366", TorrentURL: "https://nyaa.si/download/1583366.torrent",
+			MagnetURI: "magnet:?xt=urn:btih:" + knownHash + "&dn=A.mkv&tr=udp%3A%2F%2Ftracker.example%3A80",
+		},
+	}
+	if _, err := writeDataFiles(dir, "episodes", archive); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := writeDataFiles(dir, "releases", releases); err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := BackfillLinks(dir, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Incomplete != 3 || report.Completed != 1 || len(report.Entries) != 3 {
+		t.Fatalf("report = %+v", report)
+	}
+	byCRC := map[string]model.LinkBackfillEntry{}
+	for _, e := range report.Entries {
+		byCRC[e.CRC32] = e
+	}
+	if e := byCRC["AAAAAAAA"]; len(e.Missing) != 0 || strings.Join(e.Sources, ",") != model.LinkSourceReleaseInfoHash || e.SearchURL == "" {
+		t.Errorf("AAAAAAAA = %+v", e)
+	}
+	if e := byCRC["BBBBBBBB"]; strings.Join(e.Filled, ",") != "torrent_url" || strings.Join(e.Missing, ",") != "magnet_uri" {
+		t.Errorf("BBBBBBBB = %+v", e)
+	}
+	if e := byCRC["CCCCCCCC"]; strings.Join(e.Sources, ",") != model.LinkSourceInfoHash || strings.Join(e.Missing, ",") != "url,torrent_url" {
+		t.Errorf("CCCCCCCC = %+v", e)
+	}
+
+	saved, err := LoadEpisodesArchive(filepath.Join(dir, "episodes.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := saved["AAAAAAAA"].File
+	if a.URL != "https://nyaa.si/view/1583366" || a.MagnetURI != "magnet:?xt=urn:btih:"+knownHash+"&dn=A.mkv" ||
+		a.ReleaseInfoHash != knownHash || a.Resolution != "1080p" || a.Magnet == nil {
+		t.Errorf("AAAAAAAA file = %+v", a)
+	}
+	if b := saved["BBBBBBBB"].File; b.TorrentURL != "https://nyaa.si/download/42.torrent" {
+		t.Errorf("BBBBBBBB file = %+v", b)
+	}
+	if c := saved["CCCCCCCC"].File; c.URL != "https://nyaa.si/?q="+unknownHash || c.MagnetURI != "magnet:?xt=urn:btih:"+unknownHash {
+		t.Errorf("CCCCCCCC file = %+v, want the search link kept and a bare magnet", c)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "reports", "links.json")); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,34 @@
+package export
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"metadata-service/internal/model"
+	"metadata-service/internal/util"
+)
+
+// WriteGuideReport writes the episode guide scrape's report to
+// reports/guide.json under outDir, warning when description rows or arcs
+// went unmatched or episode sheets had problems.
+func WriteGuideReport(report model.GuideReport, outDir string) error {
+	reportsDir := outDir + "/reports"
+	if err := util.EnsureDir(reportsDir); err != nil {
+		return err
+	}
+
+	d := report.Descriptions
+	if n := len(d.UnmatchedArcs) + len(d.UnmatchedRows); n > 0 {
+		fmt.Printf("Warning: %d arcs / description rows unmatched, see reports/guide.json\n", n)
+	}
+	if n := len(report.Warnings); n > 0 {
+		fmt.Printf("Warning: %d episode sheet problems, see reports/guide.json\n", n)
+	}
+
+	reportJSON, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = writeFileIfChanged(reportsDir+"/guide.json", reportJSON)
+	return err
+}
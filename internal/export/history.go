@@ -0,0 +1,157 @@
+package export
+
+import (
+	"sort"
+	"time"
+
+	"metadata-service/internal/model"
+	"metadata-service/internal/parse"
+)
+
+// BuildEpisodeHistory lists, per EpisodeID, every file the episode has
+// been released as: each archived CRC, joined to its release for the
+// infohash, publish time, status and changelog, plus any release matched
+// to the episode whose CRC never made it into the sheet. Versions are
+// oldest first by the sheet's release date, falling back to the feed's
+// publish time; files with neither sort last. Archive entries without an
+// EpisodeID can't be placed and are left out.
+func BuildEpisodeHistory(arcs []model.Arc, archive EpisodesArchive, releases ReleasesArchive) map[string]model.EpisodeHistory {
+	releasesByCRC := make(map[string]model.Release)
+	for _, r := range releases {
+		if r.CRC32 == "" {
+			continue
+		}
+		// Prefer the release that's still live when a CRC was re-listed.
+		if existing, ok := releasesByCRC[r.CRC32]; ok && existing.Status != model.ReleaseOutdated {
+			continue
+		}
+		releasesByCRC[r.CRC32] = r
+	}
+
+	histories := make(map[string]*model.EpisodeHistory)
+	for _, arc := range arcs {
+		for _, ep := range arc.Episodes {
+			if ep.ID == "" {
+				continue
+			}
+			histories[ep.ID] = &model.EpisodeHistory{
+				ArcID:     arc.ID,
+				EpisodeID: ep.ID,
+				Arc:       arc.Arc,
+				Episode:   ep.Episode,
+				Title:     ep.Title,
+			}
+		}
+	}
+	history := func(episodeID string) *model.EpisodeHistory {
+		h, ok := histories[episodeID]
+		if !ok {
+			h = &model.EpisodeHistory{EpisodeID: episodeID}
+			histories[episodeID] = h
+		}
+		return h
+	}
+
+	seenCRCs := make(map[string]bool)
+	for crc, entry := range archive {
+		if entry.EpisodeID == "" {
+			continue
+		}
+		seenCRCs[crc] = true
+		h := history(entry.EpisodeID)
+		if h.ArcID == "" {
+			h.ArcID, h.Arc, h.Episode, h.Title = entry.ArcID, entry.Arc, entry.Episode, entry.Title
+		}
+		v := model.EpisodeVersion{
+			CRC32:      crc,
+			Version:    entry.File.Version,
+			Resolution: entry.File.Resolution,
+			Released:   entry.Released,
+			InfoHash:   entry.File.ReleaseInfoHash,
+			IsCurrent:  entry.IsCurrent,
+		}
+		if r, ok := releasesByCRC[crc]; ok {
+			v.InfoHash = r.InfoHash
+			v.PublishedAt = r.PublishedAt
+			v.Status = r.Status
+			v.Changelog = releaseChangelog(r)
+		}
+		h.Versions = append(h.Versions, versionChanges(v))
+	}
+
+	for _, r := range releases {
+		if r.EpisodeID == "" || (r.CRC32 != "" && seenCRCs[r.CRC32]) {
+			continue
+		}
+		if r.CRC32 != "" {
+			seenCRCs[r.CRC32] = true
+		}
+		h := history(r.EpisodeID)
+		if h.ArcID == "" {
+			h.ArcID = r.ArcID
+		}
+		h.Versions = append(h.Versions, versionChanges(model.EpisodeVersion{
+			CRC32:       r.CRC32,
+			Version:     r.NormalizedVariant,
+			Resolution:  r.Resolution,
+			InfoHash:    r.InfoHash,
+			PublishedAt: r.PublishedAt,
+			Status:      r.Status,
+			Changelog:   releaseChangelog(r),
+		}))
+	}
+
+	out := make(map[string]model.EpisodeHistory, len(histories))
+	for id, h := range histories {
+		if len(h.Versions) == 0 {
+			continue
+		}
+		sort.SliceStable(h.Versions, func(i, j int) bool {
+			a, b := h.Versions[i], h.Versions[j]
+			ta, tb := versionTime(a), versionTime(b)
+			if !ta.Equal(tb) {
+				// Zero times (nothing known) sort last.
+				return !ta.IsZero() && (tb.IsZero() || ta.Before(tb))
+			}
+			if a.CRC32 != b.CRC32 {
+				return a.CRC32 < b.CRC32
+			}
+			return a.InfoHash < b.InfoHash
+		})
+		out[id] = *h
+	}
+	return out
+}
+
+// versionTime is when a version came out: the sheet's release date, or the
+// feed's publish time when the sheet has none.
+func versionTime(v model.EpisodeVersion) time.Time {
+	if v.Released.Known() {
+		return v.Released.Time
+	}
+	return v.PublishedAt.Time
+}
+
+// releaseChangelog returns the release's classified changelog, classifying
+// it on the fly for releases archived before ChangelogEntries existed.
+func releaseChangelog(r model.Release) []model.ChangelogEntry {
+	if r.ChangelogEntries != nil {
+		return r.ChangelogEntries
+	}
+	return parse.Changelog(r.Changelog)
+}
+
+// versionChanges fills in v.Changes from its changelog's categories, in
+// first-seen order.
+func versionChanges(v model.EpisodeVersion) model.EpisodeVersion {
+	seen := make(map[string]bool)
+	for _, entry := range v.Changelog {
+		for _, c := range entry.Categories {
+			if !seen[c] {
+				seen[c] = true
+				v.Changes = append(v.Changes, c)
+			}
+		}
+	}
+	return v
+}
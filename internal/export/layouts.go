@@ -0,0 +1,213 @@
+package export
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"slices"
+
+	"metadata-service/internal/config"
+	"metadata-service/internal/model"
+	"metadata-service/internal/util"
+)
+
+// LayoutsFile is where the sheet layouts of the last accepted run are kept,
+// under the data directory.
+const LayoutsFile = "sheet-layouts.json"
+
+// CheckSheetLayouts compares the layouts the scrape read (report.Layouts)
+// with the last run's, listing every change in report.Drift. A tab's first
+// appearance isn't drift, and tabs this run couldn't read keep their last
+// layout. When something drifted and policy is config.DriftFail, the run
+// report is written and an error returned, leaving the recorded layouts
+// as they were; otherwise the new layouts are recorded.
+func CheckSheetLayouts(report *model.GuideReport, outDir, policy string) error {
+	if policy != config.DriftFail && policy != config.DriftContinue {
+		return fmt.Errorf("unknown sheet drift policy %q (want %s or %s)", policy, config.DriftFail, config.DriftContinue)
+	}
+
+	var previous []model.SheetLayout
+	if err := loadJSON(outDir+"/"+LayoutsFile, &previous); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	bySheet := make(map[string]model.SheetLayout, len(previous))
+	for _, l := range previous {
+		bySheet[l.Sheet] = l
+	}
+
+	report.Drift = []model.LayoutDrift{}
+	read := make(map[string]bool, len(report.Layouts))
+	for _, l := range report.Layouts {
+		read[l.Sheet] = true
+		if prev, ok := bySheet[l.Sheet]; ok && prev.Fingerprint != l.Fingerprint {
+			report.Drift = append(report.Drift, diffLayout(prev, l)...)
+		}
+	}
+	for _, d := range report.Drift {
+		fmt.Printf("Warning: sheet layout changed: %s: %s\n", d.Title, d.Message)
+	}
+
+	if len(report.Drift) > 0 && policy == config.DriftFail {
+		if err := WriteGuideReport(*report, outDir); err != nil {
+			return err
+		}
+		return fmt.Errorf("%d sheet layout changes, see reports/guide.json; rerun with -drift=%s once the parser handles them", len(report.Drift), config.DriftContinue)
+	}
+
+	layouts := slices.Clone(report.Layouts)
+	for _, l := range previous {
+		if !read[l.Sheet] {
+			layouts = append(layouts, l)
+		}
+	}
+	if err := util.EnsureDir(outDir); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(layouts, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = writeFileIfChanged(outDir+"/"+LayoutsFile, data)
+	return err
+}
+
+// diffLayout lists how a tab's layout changed from prev to cur. Headers
+// are matched by their exact text: one only in prev was removed, one only
+// in cur was added, and a removal and an addition between the same
+// surviving neighbours are a rename. Surviving headers are reported as
+// moved only when their order changed (the fewest that explain it), not
+// when an insertion or removal before them shifted them along.
+func diffLayout(prev, cur model.SheetLayout) []model.LayoutDrift {
+	var drift []model.LayoutDrift
+	add := func(kind string, column int, header, previous, message string) {
+		drift = append(drift, model.LayoutDrift{
+			Sheet: cur.Sheet, ArcID: cur.ArcID, Title: cur.Title,
+			Kind: kind, Column: column, Header: header, Previous: previous, Message: message,
+		})
+	}
+
+	prevIdx, curIdx := headerIndex(prev.Header), headerIndex(cur.Header)
+	type change struct {
+		column int
+		header string
+	}
+	// Removals and additions by slot: how many surviving headers come
+	// before them.
+	removed, added := make(map[int][]change), make(map[int][]change)
+	var kept []string // surviving headers, in their old order
+	slot := 0
+	for i, h := range prev.Header {
+		if h == "" || prevIdx[h] != i {
+			continue
+		}
+		if _, ok := curIdx[h]; ok {
+			kept = append(kept, h)
+			slot++
+		} else {
+			removed[slot] = append(removed[slot], change{i, h})
+		}
+	}
+	slot = 0
+	for i, h := range cur.Header {
+		if h == "" || curIdx[h] != i {
+			continue
+		}
+		if _, ok := prevIdx[h]; ok {
+			slot++
+		} else {
+			added[slot] = append(added[slot], change{i, h})
+		}
+	}
+
+	for slot := 0; slot <= len(kept); slot++ {
+		rem, ins := removed[slot], added[slot]
+		for len(rem) > 0 && len(ins) > 0 {
+			old, now := rem[0], ins[0]
+			add(model.DriftRenamed, now.column, now.header, old.header,
+				fmt.Sprintf("column %s header %q renamed to %q (was column %s)", columnLetter(now.column), old.header, now.header, columnLetter(old.column)))
+			rem, ins = rem[1:], ins[1:]
+		}
+		for _, c := range ins {
+			add(model.DriftAdded, c.column, c.header, "", fmt.Sprintf("header %q added in column %s", c.header, columnLetter(c.column)))
+		}
+		for _, c := range rem {
+			add(model.DriftRemoved, c.column, c.header, "", fmt.Sprintf("header %q removed from column %s", c.header, columnLetter(c.column)))
+		}
+	}
+
+	inOrder := longestIncreasing(kept, curIdx)
+	for _, h := range kept {
+		if !inOrder[h] {
+			from, to := prevIdx[h], curIdx[h]
+			add(model.DriftMoved, to, h, columnLetter(from), fmt.Sprintf("header %q moved from column %s to %s", h, columnLetter(from), columnLetter(to)))
+		}
+	}
+
+	if prev.Columns != cur.Columns {
+		add(model.DriftColumns, cur.Columns, "", fmt.Sprint(prev.Columns), fmt.Sprintf("column count changed from %d to %d", prev.Columns, cur.Columns))
+	}
+
+	// Changes the above can't describe, such as a repeated header, still
+	// get reported, column by column.
+	if len(drift) == 0 {
+		for i := range max(len(prev.Header), len(cur.Header)) {
+			var was, now string
+			if i < len(prev.Header) {
+				was = prev.Header[i]
+			}
+			if i < len(cur.Header) {
+				now = cur.Header[i]
+			}
+			if was != now {
+				add(model.DriftRenamed, i, now, was, fmt.Sprintf("column %s header %q changed to %q", columnLetter(i), was, now))
+			}
+		}
+	}
+	return drift
+}
+
+// longestIncreasing returns the largest set of headers whose columns in
+// curIdx are in the same order as they are in headers.
+func longestIncreasing(headers []string, curIdx map[string]int) map[string]bool {
+	length := make([]int, len(headers))
+	from := make([]int, len(headers))
+	best := -1
+	for i, h := range headers {
+		length[i], from[i] = 1, -1
+		for j := range i {
+			if curIdx[headers[j]] < curIdx[h] && length[j]+1 > length[i] {
+				length[i], from[i] = length[j]+1, j
+			}
+		}
+		if best < 0 || length[i] > length[best] {
+			best = i
+		}
+	}
+	in := make(map[string]bool, len(headers))
+	for i := best; i >= 0; i = from[i] {
+		in[headers[i]] = true
+	}
+	return in
+}
+
+// headerIndex maps each header to the first column it's in.
+func headerIndex(header []string) map[string]int {
+	index := make(map[string]int, len(header))
+	for i, h := range header {
+		if _, ok := index[h]; !ok {
+			index[h] = i
+		}
+	}
+	return index
+}
+
+// columnLetter returns the spreadsheet name of 0-based column i: "A",
+// "B", ..., "Z", "AA".
+func columnLetter(i int) string {
+	s := ""
+	for i++; i > 0; i = (i - 1) / 26 {
+		s = string(rune('A'+(i-1)%26)) + s
+	}
+	return s
+}
@@ -0,0 +1,58 @@
+package export
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"metadata-service/internal/model"
+)
+
+// LoadEpisodesArchive reads a previously exported episodes.json. Used by the
+// read-only consumers (servers, reports) that work off the exported data
+// rather than a fresh scrape.
+func LoadEpisodesArchive(path string) (EpisodesArchive, error) {
+	archive := EpisodesArchive{}
+	if err := loadJSON(path, &archive); err != nil {
+		return nil, err
+	}
+	return archive, nil
+}
+
+// LoadReleasesArchive reads a previously exported releases.json.
+func LoadReleasesArchive(path string) (ReleasesArchive, error) {
+	archive := ReleasesArchive{}
+	if err := loadJSON(path, &archive); err != nil {
+		return nil, err
+	}
+	return archive, nil
+}
+
+func loadJSON(path string, v any) error {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(raw, v); err != nil {
+		return fmt.Errorf("decode %s: %w", path, err)
+	}
+	return nil
+}
+
+// LoadArcs reads a previously exported arcs.json.
+func LoadArcs(path string) ([]model.Arc, error) {
+	var arcs []model.Arc
+	if err := loadJSON(path, &arcs); err != nil {
+		return nil, err
+	}
+	return arcs, nil
+}
+
+// LoadCurrentEpisodes reads a previously exported episodes-current.json.
+func LoadCurrentEpisodes(path string) (map[string]model.CurrentEpisode, error) {
+	current := map[string]model.CurrentEpisode{}
+	if err := loadJSON(path, &current); err != nil {
+		return nil, err
+	}
+	return current, nil
+}
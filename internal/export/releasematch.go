@@ -0,0 +1,133 @@
+package export
+
+import (
+	"sort"
+
+	"metadata-service/internal/config"
+	"metadata-service/internal/model"
+	"metadata-service/internal/parse"
+)
+
+// releaseMatcher joins releases to arcs/episodes: by CRC32 through the
+// episode archive first (exact, and covers superseded files), then by the
+// arc title and episode number encoded in the release title, then by the
+// same in the magnet's filename.
+type releaseMatcher struct {
+	archive EpisodesArchive
+
+	// arcsByKey maps the Canonical key of each arc's title to the arc.
+	arcsByKey map[string]model.Arc
+	aliases   parse.TitleAliases
+}
+
+func newReleaseMatcher(arcs []model.Arc, archive EpisodesArchive) *releaseMatcher {
+	m := &releaseMatcher{
+		archive:   archive,
+		arcsByKey: make(map[string]model.Arc, len(arcs)),
+		aliases:   parse.NewTitleAliases(config.ArcTitleAliases),
+	}
+	for _, arc := range arcs {
+		m.arcsByKey[m.aliases.Canonical(arc.Title)] = arc
+	}
+	return m
+}
+
+// match fills in r's ArcID/EpisodeID/MatchedBy, keeping IDs already set.
+// Reports whether anything changed.
+func (m *releaseMatcher) match(r *model.Release) bool {
+	if r.ArcID != "" && r.EpisodeID != "" {
+		return false
+	}
+
+	arcID, episodeID, by := "", "", ""
+	if entry, ok := m.archive[r.CRC32]; ok && r.CRC32 != "" && entry.EpisodeID != "" {
+		arcID, episodeID, by = entry.ArcID, entry.EpisodeID, "crc32"
+	} else {
+		arcID, episodeID = m.matchTitle(parse.ReleaseTitle(r.Title))
+		by = "title"
+		if episodeID == "" && r.Magnet != nil && r.Magnet.File != nil && r.Magnet.File.Arc != "" {
+			// The filename sometimes spells the arc differently.
+			if a, e := m.matchTitle(r.Magnet.File.Arc, r.Magnet.File.Episode); e != "" || arcID == "" {
+				arcID, episodeID, by = a, e, "filename"
+			}
+		}
+	}
+
+	changed := false
+	if r.ArcID == "" && arcID != "" {
+		r.ArcID = arcID
+		r.MatchedBy = by
+		changed = true
+	}
+	if r.EpisodeID == "" && episodeID != "" {
+		r.EpisodeID = episodeID
+		r.MatchedBy = by
+		changed = true
+	}
+	return changed
+}
+
+// matchTitle resolves an arc title (see parse.ReleaseTitle) to an arc and,
+// when there's an episode number, to that episode. A title without a
+// number names a whole-arc batch, except for single-episode arcs, where
+// it's the episode itself (e.g. "Gaimon").
+func (m *releaseMatcher) matchTitle(arcTitle string, episode *int) (arcID, episodeID string) {
+	arc, ok := m.arcsByKey[m.aliases.Canonical(arcTitle)]
+	if !ok {
+		return "", ""
+	}
+	if episode == nil {
+		if len(arc.Episodes) == 1 {
+			return arc.ID, arc.Episodes[0].ID
+		}
+		return arc.ID, ""
+	}
+	for _, ep := range arc.Episodes {
+		if ep.Episode == *episode {
+			return arc.ID, ep.ID
+		}
+	}
+	return arc.ID, ""
+}
+
+// buildReleaseMatchReport tallies the match methods across the releases
+// archive and lists the releases left without an episode, sorted by
+// title for stable output.
+func buildReleaseMatchReport(releases ReleasesArchive) model.ReleaseMatchReport {
+	var report model.ReleaseMatchReport
+	for _, r := range releases {
+		if r.EpisodeID != "" {
+			switch r.MatchedBy {
+			case "crc32":
+				report.ByCRC32++
+			case "title":
+				report.ByTitle++
+			case "filename":
+				report.ByFilename++
+			}
+			continue
+		}
+		u := model.UnmatchedRelease{
+			InfoHash:    r.InfoHash,
+			Title:       r.Title,
+			Variant:     r.Variant,
+			CRC32:       r.CRC32,
+			PublishedAt: r.PublishedAt,
+			ArcID:       r.ArcID,
+		}
+		if r.ArcID != "" {
+			report.ArcOnly = append(report.ArcOnly, u)
+		} else {
+			report.Unmatched = append(report.Unmatched, u)
+		}
+	}
+	for _, list := range [][]model.UnmatchedRelease{report.ArcOnly, report.Unmatched} {
+		sort.Slice(list, func(a, b int) bool {
+			if list[a].Title != list[b].Title {
+				return list[a].Title < list[b].Title
+			}
+			return list[a].InfoHash < list[b].InfoHash
+		})
+	}
+	return report
+}
@@ -0,0 +1,23 @@
+package export
+
+import "metadata-service/internal/model"
+
+// BuildSagas groups arcs into their sagas (see Arc.SagaID), numbered in
+// order of each saga's first arc. Arcs without a saga are left out.
+func BuildSagas(arcs []model.Arc) []model.Saga {
+	sagas := []model.Saga{}
+	byID := make(map[string]int)
+	for _, arc := range arcs {
+		if arc.SagaID == "" {
+			continue
+		}
+		i, ok := byID[arc.SagaID]
+		if !ok {
+			i = len(sagas)
+			byID[arc.SagaID] = i
+			sagas = append(sagas, model.Saga{ID: arc.SagaID, Number: i + 1, Title: arc.Saga})
+		}
+		sagas[i].ArcIDs = append(sagas[i].ArcIDs, arc.ID)
+	}
+	return sagas
+}
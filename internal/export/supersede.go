@@ -0,0 +1,107 @@
+package export
+
+import (
+	"fmt"
+	"sort"
+
+	"metadata-service/internal/config"
+	"metadata-service/internal/model"
+	"metadata-service/internal/parse"
+)
+
+// outdatedCRCs returns the CRC32s whose releases the feed has all marked
+// outdated. A CRC re-listed under an active release isn't outdated.
+func outdatedCRCs(releases ReleasesArchive) map[string]bool {
+	outdated := make(map[string]bool)
+	active := make(map[string]bool)
+	for _, r := range releases {
+		if r.CRC32 == "" {
+			continue
+		}
+		if r.Status == model.ReleaseOutdated {
+			outdated[r.CRC32] = true
+		} else {
+			active[r.CRC32] = true
+		}
+	}
+	for crc := range active {
+		delete(outdated, crc)
+	}
+	return outdated
+}
+
+// linkSuperseded points every outdated release at the release that
+// replaced it: the next one published for the same episode and cut,
+// preferring one at the same resolution, or failing that a later batch of
+// the whole arc. Releases are grouped by the arc and episode in their
+// title (arc aliases applied), since a re-release keeps its title even
+// when its CRC — and so possibly its EpisodeID match — changes.
+// SupersededBy is derived, so it's recomputed on every run. Reports
+// whether anything changed.
+func linkSuperseded(releases ReleasesArchive) bool {
+	aliases := parse.NewTitleAliases(config.ArcTitleAliases)
+	groupKey := func(r model.Release) (episodeKey, batchKey string) {
+		arc, episode := parse.ReleaseTitle(r.Title)
+		arcKey := aliases.Canonical(arc)
+		batchKey = arcKey + "#batch|" + r.NormalizedVariant
+		if episode == nil {
+			return batchKey, batchKey
+		}
+		return fmt.Sprintf("%s#%d|%s", arcKey, *episode, r.NormalizedVariant), batchKey
+	}
+
+	groups := make(map[string][]model.Release)
+	for _, r := range releases {
+		if !r.PublishedAt.Known() {
+			continue
+		}
+		key, _ := groupKey(r)
+		groups[key] = append(groups[key], r)
+	}
+	for _, group := range groups {
+		sort.Slice(group, func(a, b int) bool {
+			return group[b].PublishedAt.After(group[a].PublishedAt)
+		})
+	}
+
+	successor := make(map[string]string)
+	for _, r := range releases {
+		if r.Status != model.ReleaseOutdated || !r.PublishedAt.Known() {
+			continue
+		}
+		key, batchKey := groupKey(r)
+		next := nextRelease(r, groups[key])
+		if next == "" && key != batchKey {
+			next = nextRelease(r, groups[batchKey])
+		}
+		successor[r.InfoHash] = next
+	}
+
+	changed := false
+	for hash, r := range releases {
+		if want := successor[hash]; r.SupersededBy != want {
+			r.SupersededBy = want
+			releases[hash] = r
+			changed = true
+		}
+	}
+	return changed
+}
+
+// nextRelease returns the InfoHash of the first release in group (sorted
+// oldest first) published after r, preferring r's resolution.
+func nextRelease(r model.Release, group []model.Release) string {
+	next := ""
+	for _, later := range group {
+		if !later.PublishedAt.After(r.PublishedAt) {
+			continue
+		}
+		if later.Resolution == r.Resolution {
+			return later.InfoHash
+		}
+		if next == "" {
+			next = later.InfoHash
+		}
+	}
+	return next
+}
@@ -0,0 +1,158 @@
+package export
+
+import (
+	"net/url"
+	"slices"
+	"sort"
+	"strings"
+
+	"metadata-service/internal/config"
+	"metadata-service/internal/model"
+	"metadata-service/internal/parse"
+	"metadata-service/internal/util"
+)
+
+// trackerSet records, per tracker URL, the infohashes of the releases seen
+// announcing it, so the same release counted from the feed and from the
+// archive isn't counted twice.
+type trackerSet map[string]map[string]bool
+
+// compactMagnet records uri's trackers in seen and returns the compact
+// (infohash + filename) form of uri, plus m without its trackers. Reports
+// whether either changed.
+func (seen trackerSet) compactMagnet(uri string, m *model.Magnet) (string, *model.Magnet, bool) {
+	if uri == "" {
+		return uri, m, false
+	}
+	if full := parse.Magnet(uri); full != nil {
+		key := full.InfoHash
+		if key == "" {
+			key = full.InfoHashV2
+		}
+		for _, tr := range full.Trackers {
+			if seen[tr] == nil {
+				seen[tr] = make(map[string]bool)
+			}
+			seen[tr][key] = true
+		}
+	}
+
+	compact := parse.CompactMagnet(uri)
+	changed := compact != uri
+	if m != nil && len(m.Trackers) > 0 {
+		trimmed := *m
+		trimmed.Trackers = nil
+		m = &trimmed
+		changed = true
+	}
+	return compact, m, changed
+}
+
+func (seen trackerSet) compactFile(f *model.EpisodeFile) bool {
+	var changed bool
+	f.MagnetURI, f.Magnet, changed = seen.compactMagnet(f.MagnetURI, f.Magnet)
+	return changed
+}
+
+func (seen trackerSet) compactRelease(r *model.Release) bool {
+	var changed bool
+	r.MagnetURI, r.Magnet, changed = seen.compactMagnet(r.MagnetURI, r.Magnet)
+	return changed
+}
+
+// buildTrackerRegistry merges the trackers seen this run into the previous
+// registry. Like the archives it's append-only: a tracker no longer seen
+// keeps its entry and count. Every tracker in config.MagnetTrackers is
+// listed, even if no release has announced it yet; every tracker not in
+// config.TrackerDenylist is active.
+func buildTrackerRegistry(previous []model.Tracker, seen trackerSet) []model.Tracker {
+	byURL := make(map[string]model.Tracker, len(previous)+len(seen))
+	for _, t := range previous {
+		byURL[t.URL] = t
+	}
+	for url, hashes := range seen {
+		t := byURL[url]
+		t.URL = url
+		t.Releases = max(t.Releases, len(hashes))
+		byURL[url] = t
+	}
+
+	for _, url := range config.MagnetTrackers {
+		if _, ok := byURL[url]; !ok {
+			byURL[url] = model.Tracker{URL: url}
+		}
+	}
+
+	registry := make([]model.Tracker, 0, len(byURL))
+	for url, t := range byURL {
+		t.Active = !trackerDenied(url)
+		registry = append(registry, t)
+	}
+	sort.Slice(registry, func(a, b int) bool {
+		if registry[a].Releases != registry[b].Releases {
+			return registry[a].Releases > registry[b].Releases
+		}
+		return registry[a].URL < registry[b].URL
+	})
+	return registry
+}
+
+// activeTrackers lists the registry's active trackers, most announced
+// first, re-checking config.TrackerDenylist in case it changed since the
+// registry was written. These are the trackers output magnets get.
+func activeTrackers(registry []model.Tracker) []string {
+	var trackers []string
+	for _, t := range registry {
+		if !trackerDenied(t.URL) {
+			trackers = append(trackers, t.URL)
+		}
+	}
+	return trackers
+}
+
+// trackerDenied reports whether tracker's host is in config.TrackerDenylist.
+func trackerDenied(tracker string) bool {
+	u, err := url.Parse(tracker)
+	if err != nil {
+		return false
+	}
+	return slices.Contains(config.TrackerDenylist, strings.ToLower(u.Hostname()))
+}
+
+// LoadTrackers reads the tracker registry (trackers.json) and returns the
+// trackers to add back to magnets on output. Without a registry that's
+// config.MagnetTrackers, less any on config.TrackerDenylist.
+func LoadTrackers(path string) ([]string, error) {
+	var registry []model.Tracker
+	if util.FileExists(path) {
+		if err := loadJSON(path, &registry); err != nil {
+			return nil, err
+		}
+	}
+	return activeTrackers(buildTrackerRegistry(registry, nil)), nil
+}
+
+// FileWithTrackers returns f with its magnet rebuilt from the compact
+// stored form using trackers (see LoadTrackers), for output.
+func FileWithTrackers(f model.EpisodeFile, trackers []string) model.EpisodeFile {
+	f.MagnetURI, f.Magnet = fullMagnet(f.MagnetURI, f.Magnet, trackers)
+	return f
+}
+
+// ReleaseWithTrackers does the same for a release.
+func ReleaseWithTrackers(r model.Release, trackers []string) model.Release {
+	r.MagnetURI, r.Magnet = fullMagnet(r.MagnetURI, r.Magnet, trackers)
+	return r
+}
+
+func fullMagnet(uri string, m *model.Magnet, trackers []string) (string, *model.Magnet) {
+	if uri == "" {
+		return uri, m
+	}
+	if m != nil {
+		withTrackers := *m
+		withTrackers.Trackers = trackers
+		m = &withTrackers
+	}
+	return parse.FullMagnet(uri, trackers), m
+}
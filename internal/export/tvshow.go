@@ -0,0 +1,51 @@
+package export
+
+import (
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+
+	"metadata-service/internal/config"
+	"metadata-service/internal/model"
+)
+
+// buildTVShow renders config.TVShowTemplate and fills in the live,
+// data-derived facts from this run's arcs. The show is "Continuing" while
+// any arc is still a work in progress (Status "WIP") or has no released
+// episodes yet; otherwise it's "Ended". TBR arcs are released (just slated
+// for a redo), so they don't keep the show open on their own.
+func buildTVShow(arcs []model.Arc) (model.TVShow, error) {
+	var show model.TVShow
+	if err := yaml.Unmarshal(config.TVShowTemplate, &show); err != nil {
+		return show, fmt.Errorf("parse tvshow template: %w", err)
+	}
+
+	show.Status = "Ended"
+	show.ArcCount = len(arcs)
+	var latest model.Date
+	for _, arc := range arcs {
+		if arc.Status == "WIP" || len(arc.Episodes) == 0 {
+			show.Status = "Continuing"
+		}
+		for _, ep := range arc.Episodes {
+			show.EpisodeCount++
+			if ep.Released.After(latest) {
+				latest = ep.Released
+			}
+			// One runtime per episode: the normal cut when there is one,
+			// so extended cuts aren't double-counted.
+			switch {
+			case ep.Files.Normal != nil:
+				show.TotalRuntimeSeconds += ep.Files.Normal.LengthSeconds
+			case ep.Files.Extended != nil:
+				show.TotalRuntimeSeconds += ep.Files.Extended.LengthSeconds
+			}
+		}
+	}
+
+	if latest.Known() {
+		show.LatestRelease = latest.String()
+	}
+
+	return show, nil
+}
@@ -5,41 +5,101 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"sort"
 	"strconv"
 	"strings"
 
 	"metadata-service/internal/config"
 	"metadata-service/internal/model"
+	"metadata-service/internal/parse"
 )
 
 //
 // Episode description CSV row (gid=0)
-// arc_title, arc_part, title_en, description_en
+// arc_title, arc_part, title_en, description_en[, title_<lang>, description_<lang>, ..., saga]
 //
 
-func FetchEpisodeDescriptions() (map[string]map[int]model.EpisodeMeta, error) {
+// FetchEpisodeDescriptions reads the episode descriptions sheet: per arc
+// title and episode number, the episode's title and description in every
+// language it has — the main tab's per-language columns plus any tabs in
+// config.OnePaceEpisodeDescLanguageGIDs. When the main tab has a "saga"
+// column, sagas maps each arc title to its saga.
+func FetchEpisodeDescriptions() (meta map[string]map[int]model.EpisodeMeta, sagas map[string]string, err error) {
+	// map["Romance Dawn"][1] = EpisodeMeta{Title, Description, ...}
+	meta = make(map[string]map[int]model.EpisodeMeta)
+	sagas = make(map[string]string)
+
+	if err := readDescriptionSheet("0", model.DefaultLanguage, meta, sagas); err != nil {
+		return nil, nil, err
+	}
+
+	tags := make([]string, 0, len(config.OnePaceEpisodeDescLanguageGIDs))
+	for tag := range config.OnePaceEpisodeDescLanguageGIDs {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+	for _, tag := range tags {
+		gid := config.OnePaceEpisodeDescLanguageGIDs[tag]
+		if err := readDescriptionSheet(gid, tag, meta, nil); err != nil {
+			fmt.Printf("Warning: failed to fetch %s episode descriptions: %v\n", tag, err)
+		}
+	}
 
-	url := fmt.Sprintf("https://docs.google.com/spreadsheets/d/%s/export?format=csv&gid=0",
-		config.OnePaceEpisodeDescID,
+	return meta, sagas, nil
+}
+
+// readDescriptionSheet fetches one tab of the descriptions sheet and reads
+// it into meta; see parseDescriptionSheet.
+func readDescriptionSheet(gid, lang string, meta map[string]map[int]model.EpisodeMeta, sagas map[string]string) error {
+	url := fmt.Sprintf("https://docs.google.com/spreadsheets/d/%s/export?format=csv&gid=%s",
+		config.OnePaceEpisodeDescID, gid,
 	)
 
 	resp, err := http.Get(url)
 	if err != nil {
-		return nil, fmt.Errorf("fetch episode descriptions CSV: %w", err)
+		return fmt.Errorf("fetch episode descriptions CSV: %w", err)
 	}
 	defer resp.Body.Close()
+	return parseDescriptionSheet(resp.Body, lang, meta, sagas)
+}
 
-	reader := csv.NewReader(resp.Body)
+// parseDescriptionSheet reads one tab of the descriptions sheet (as CSV)
+// into meta. On the main tab (lang is model.DefaultLanguage), columns named
+// like "title_es" / "description_pt-br" (see parse.LocalizedColumn) are
+// read per language, and English fills EpisodeMeta.Title/Description. An
+// extra language tab holds only lang: its title and description columns
+// are read as lang whatever their suffix (a tab copied from the main one
+// keeps "title_en"), or are columns 2 and 3 without any. sagas is nil for
+// tabs whose saga column (if any) should be ignored.
+func parseDescriptionSheet(r io.Reader, lang string, meta map[string]map[int]model.EpisodeMeta, sagas map[string]string) error {
+	reader := csv.NewReader(r)
 	reader.FieldsPerRecord = -1
 
-	// Skip header row
-	_, err = reader.Read()
+	header, err := reader.Read()
 	if err != nil {
-		return nil, fmt.Errorf("read header: %w", err)
+		return fmt.Errorf("read header: %w", err)
 	}
 
-	// map["Romance Dawn"][1] = EpisodeMeta{Title, Description}
-	result := make(map[string]map[int]model.EpisodeMeta)
+	type column struct {
+		index      int
+		field, tag string
+	}
+	var columns []column
+	sagaCol := -1
+	for i, name := range header {
+		if field, tag, ok := parse.LocalizedColumn(name); ok {
+			if lang != model.DefaultLanguage {
+				tag = lang
+			}
+			columns = append(columns, column{i, field, tag})
+		}
+		if strings.EqualFold(strings.TrimSpace(name), "saga") {
+			sagaCol = i
+		}
+	}
+	if len(columns) == 0 {
+		columns = []column{{2, "title", lang}, {3, "description", lang}}
+	}
 
 	for {
 		row, err := reader.Read()
@@ -47,19 +107,16 @@ func FetchEpisodeDescriptions() (map[string]map[int]model.EpisodeMeta, error) {
 			break
 		}
 		if err != nil {
-			return nil, fmt.Errorf("csv read: %w", err)
+			return fmt.Errorf("csv read: %w", err)
 		}
 
-		if len(row) < 4 {
+		if len(row) < 2 {
 			continue
 		}
 
 		arcTitle := strings.TrimSpace(row[0])
 		epStr := strings.TrimSpace(row[1])
-		title := strings.TrimSpace(row[2])
-		desc := strings.TrimSpace(row[3])
-
-		if arcTitle == "" || epStr == "" || title == "" {
+		if arcTitle == "" || epStr == "" {
 			continue
 		}
 
@@ -70,17 +127,117 @@ func FetchEpisodeDescriptions() (map[string]map[int]model.EpisodeMeta, error) {
 
 		arcTitle = cleanTitle(arcTitle)
 
-		if _, ok := result[arcTitle]; !ok {
-			result[arcTitle] = make(map[int]model.EpisodeMeta)
+		if sagas != nil && sagaCol >= 0 && sagaCol < len(row) {
+			if saga := strings.TrimSpace(row[sagaCol]); saga != "" {
+				sagas[arcTitle] = saga
+			}
 		}
 
-		result[arcTitle][epNum] = model.EpisodeMeta{
-			Title:       title,
-			Description: desc,
+		m := meta[arcTitle][epNum]
+		for _, col := range columns {
+			if col.index >= len(row) {
+				continue
+			}
+			text := strings.TrimSpace(row[col.index])
+			if text == "" {
+				continue
+			}
+			switch col.field {
+			case "title":
+				if m.Titles == nil {
+					m.Titles = model.Localized{}
+				}
+				m.Titles[col.tag] = text
+			case "description":
+				if m.Descriptions == nil {
+					m.Descriptions = model.Localized{}
+				}
+				m.Descriptions[col.tag] = text
+			}
+		}
+		// A row with no title in any language is a placeholder.
+		if len(m.Titles) == 0 {
+			continue
+		}
+		if lang == model.DefaultLanguage {
+			m.Title = m.Titles[model.DefaultLanguage]
+			m.Description = m.Descriptions[model.DefaultLanguage]
+		}
+		if _, ok := meta[arcTitle]; !ok {
+			meta[arcTitle] = make(map[int]model.EpisodeMeta)
 		}
+		meta[arcTitle][epNum] = m
 	}
 
-	return result, nil
+	return nil
+}
+
+// mergeDescriptions sets each episode's Title and Description from the
+// description rows of its arc. The sheets don't always spell an arc's
+// title the same way, so rows are joined by parse.MatchTitles (with
+// config.ArcTitleAliases) rather than exact title; each set of rows goes
+// to the arc it matches best, and to at most one arc.
+func mergeDescriptions(arcs []model.Arc, desc map[string]map[int]model.EpisodeMeta) model.DescriptionMatchReport {
+	report := model.DescriptionMatchReport{
+		Inexact:         []model.DescriptionMatch{},
+		UnmatchedArcs:   []model.DescriptionMatch{},
+		UnmatchedRows:   []string{},
+		MissingEpisodes: []string{},
+	}
+
+	rows := make([]string, 0, len(desc))
+	for title := range desc {
+		rows = append(rows, title)
+	}
+	sort.Strings(rows)
+
+	arcTitles := make([]string, len(arcs))
+	for i, arc := range arcs {
+		arcTitles[i] = arc.Title
+	}
+	matched := make(map[int]model.DescriptionMatch)
+	taken := make(map[string]bool)
+	for i, m := range parse.MatchTitles(arcTitles, rows, parse.NewTitleAliases(config.ArcTitleAliases)) {
+		taken[m.Title] = true
+		matched[i] = model.DescriptionMatch{ArcID: arcs[i].ID, Arc: arcs[i].Title, Rows: m.Title, MatchedBy: m.By}
+	}
+
+	for i := range arcs {
+		m, ok := matched[i]
+		if !ok && len(arcs[i].Episodes) == 0 {
+			continue // nothing to describe yet
+		}
+		if !ok {
+			report.UnmatchedArcs = append(report.UnmatchedArcs, model.DescriptionMatch{ArcID: arcs[i].ID, Arc: arcs[i].Title})
+			continue
+		}
+		if m.MatchedBy != parse.TitleMatchExact {
+			fmt.Printf("Warning: arc %q matched description rows %q by %s title\n", m.Arc, m.Rows, m.MatchedBy)
+			report.Inexact = append(report.Inexact, m)
+		}
+		set := desc[m.Rows]
+		for idx := range arcs[i].Episodes {
+			ep := &arcs[i].Episodes[idx]
+			meta, ok := set[ep.Episode]
+			if !ok {
+				report.MissingEpisodes = append(report.MissingEpisodes, ep.ID)
+				continue
+			}
+			// Episodes with no English row keep the episode sheet's title.
+			if meta.Title != "" {
+				ep.Title = meta.Title
+				ep.Description = meta.Description
+			}
+			ep.Titles = meta.Titles
+			ep.Descriptions = meta.Descriptions
+		}
+	}
+	for _, r := range rows {
+		if !taken[r] {
+			report.UnmatchedRows = append(report.UnmatchedRows, r)
+		}
+	}
+	return report
 }
 
 func cleanTitle(s string) string {
@@ -0,0 +1,50 @@
+package fetch
+
+import (
+	"strings"
+	"testing"
+
+	"metadata-service/internal/model"
+)
+
+func TestParseDescriptionSheetLanguageTabs(t *testing.T) {
+	meta := make(map[string]map[int]model.EpisodeMeta)
+	sagas := make(map[string]string)
+
+	main := "arc_title,arc_part,title_en,description_en,saga\n" +
+		"Romance Dawn,1,Romance Dawn,Luffy sets out.,East Blue\n"
+	if err := parseDescriptionSheet(strings.NewReader(main), model.DefaultLanguage, meta, sagas); err != nil {
+		t.Fatal(err)
+	}
+	// Extra language tabs copied from the main one keep its "_en" headers.
+	tabs := map[string]string{
+		"es":    "arc_title,arc_part,title_en,description_en\nRomance Dawn,1,El amanecer,Luffy zarpa.\n",
+		"pt-BR": "arc_title,arc_part,title_en,description_en,saga\nRomance Dawn,1,O amanhecer,Luffy parte.,Mar Leste\n",
+	}
+	for _, lang := range []string{"es", "pt-BR"} {
+		if err := parseDescriptionSheet(strings.NewReader(tabs[lang]), lang, meta, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	m := meta["Romance Dawn"][1]
+	if m.Title != "Romance Dawn" || m.Description != "Luffy sets out." {
+		t.Errorf("English defaults = %q / %q, overwritten by a language tab", m.Title, m.Description)
+	}
+	want := map[string][2]string{
+		"en":    {"Romance Dawn", "Luffy sets out."},
+		"es":    {"El amanecer", "Luffy zarpa."},
+		"pt-BR": {"O amanhecer", "Luffy parte."},
+	}
+	for lang, w := range want {
+		if m.Titles[lang] != w[0] || m.Descriptions[lang] != w[1] {
+			t.Errorf("%s = %q / %q, want %q / %q", lang, m.Titles[lang], m.Descriptions[lang], w[0], w[1])
+		}
+	}
+	if len(m.Titles) != len(want) {
+		t.Errorf("titles = %v", m.Titles)
+	}
+	if sagas["Romance Dawn"] != "East Blue" {
+		t.Errorf("saga = %q, want the main tab's", sagas["Romance Dawn"])
+	}
+}
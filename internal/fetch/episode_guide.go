@@ -24,30 +24,57 @@ var crcRe = regexp.MustCompile(`^[0-9A-Fa-f]{8}$`)
 // ===== PUBLIC ENTRY =====
 //
 
-// FetchEpisodeGuideHome parses the main arc list (HTML) + all arc CSVs.
-func FetchEpisodeGuideHome() ([]model.Arc, error) {
+// FetchEpisodeGuideHome parses the main arc list (HTML) + all arc CSVs,
+// and reports what it couldn't place.
+func FetchEpisodeGuideHome() ([]model.Arc, model.GuideReport, error) {
 
-	arcs, err := fetchArcList(config.OnePaceEpisodeGuide)
+	arcs, layout, err := fetchArcList(config.OnePaceEpisodeGuide)
 	if err != nil {
-		return nil, fmt.Errorf("fetchArcList: %w", err)
+		return nil, model.GuideReport{}, fmt.Errorf("fetchArcList: %w", err)
 	}
 
 	arcs = normalizeArcIDs(arcs)
 
+	report := model.GuideReport{
+		Warnings: []model.SheetWarning{},
+		Layouts:  []model.SheetLayout{layout},
+	}
+
 	for i := range arcs {
 		if arcs[i].GID == "" {
 			continue
 		}
 		fmt.Printf("Fetching - %d - %s.\n", arcs[i].Arc, arcs[i].Title)
-		episodes, err := fetchArcEpisodes(config.OnePaceEpisodeGuide, arcs[i].GID)
+		episodes, layout, warnings, err := fetchArcEpisodes(config.OnePaceEpisodeGuide, arcs[i].GID)
+		for _, w := range warnings {
+			w.ArcID = arcs[i].ID
+			report.Warnings = append(report.Warnings, w)
+		}
 		if err != nil {
 			fmt.Printf("Warning: failed to fetch episodes for arc %d: %v\n", arcs[i].Arc, err)
+			report.Warnings = append(report.Warnings, model.SheetWarning{
+				ArcID: arcs[i].ID, Sheet: arcs[i].GID, Message: "sheet not read: " + err.Error(),
+			})
 			continue
 		}
+		layout.ArcID, layout.Title = arcs[i].ID, arcs[i].Title
+		report.Layouts = append(report.Layouts, layout)
+
+		// The sheet only states resolution per arc, so it's only safe to
+		// stamp onto the files when the arc has a single one.
+		arcResolution := ""
+		if len(arcs[i].ResolutionList) == 1 {
+			arcResolution = arcs[i].ResolutionList[0]
+		}
 
 		for idx := range episodes {
 			episodes[idx].Arc = arcs[i].Arc
 			episodes[idx].ID = fmt.Sprintf("%s-%03d", arcs[i].ID, episodes[idx].Episode)
+			for _, file := range []*model.EpisodeFile{episodes[idx].Files.Normal, episodes[idx].Files.Extended} {
+				if file != nil && file.Resolution == "" {
+					file.Resolution = arcResolution
+				}
+			}
 		}
 
 		arcs[i].Episodes = append(arcs[i].Episodes, episodes...)
@@ -58,28 +85,68 @@ func FetchEpisodeGuideHome() ([]model.Arc, error) {
 	}
 
 	// Merge descriptions
-	desc, err := FetchEpisodeDescriptions()
+	desc, sheetSagas, err := FetchEpisodeDescriptions()
 	if err == nil {
-		for i := range arcs {
-			set, ok := desc[arcs[i].Title]
-			if !ok {
-				continue
-			}
-			for idx := range arcs[i].Episodes {
-				ep := &arcs[i].Episodes[idx]
-				if meta, ok := set[ep.Episode]; ok {
-					ep.Title = meta.Title
-					ep.Description = meta.Description
-				}
-			}
-		}
+		report.Descriptions = mergeDescriptions(arcs, desc)
+	} else {
+		fmt.Println("Warning: failed to fetch episode descriptions:", err)
 	}
 
-	return arcs, nil
+	if err := assignSagas(arcs, sheetSagas); err != nil {
+		return nil, report, err
+	}
+
+	return arcs, report, nil
 }
 
-// fetchArcList reads the main Google Sheet HTML arc list.
-func fetchArcList(spreadsheetID string) ([]model.Arc, error) {
+// Arc list fields (arcListColumns).
+const (
+	colArcNumber         = "number"
+	colArcTitle          = "title"
+	colMangaChapters     = "manga_chapters"
+	colNumberOfChapters  = "number_of_chapters"
+	colAnimeEpisodes     = "anime_episodes"
+	colEpisodesAdapted   = "episodes_adapted"
+	colFillerEpisodes    = "filler_episodes"
+	colTimeSavedMins     = "time_saved_mins"
+	colTimeSavedPercent  = "time_saved_percent"
+	colAudioLanguages    = "audio_languages"
+	colSubtitleLanguages = "subtitle_languages"
+	colResolution        = "resolution"
+	colSaga              = "saga"
+	colOnePaceEpisodes   = "one_pace_episodes"
+	colAnimeRuntime      = "anime_runtime"
+	colOnePaceRuntime    = "one_pace_runtime"
+)
+
+// arcListColumns maps the arc list's header names to fields, so inserted
+// or reordered columns don't shift every field after them. The first name
+// is the sheet's current one; the others are spellings worth accepting.
+// The run stops if a required column can't be found.
+var arcListColumns = []parse.Column{
+	{Field: colArcNumber, Headers: []string{"No.", "Number", "Arc No."}, Required: true},
+	{Field: colArcTitle, Headers: []string{"Arcs", "Arc", "Title"}, Required: true},
+	{Field: colMangaChapters, Headers: []string{"Manga Chapters", "Chapters"}, Required: true},
+	{Field: colNumberOfChapters, Headers: []string{"No. of Chapters", "Number of Chapters", "# of Chapters"}},
+	{Field: colAnimeEpisodes, Headers: []string{"Anime Episodes"}, Required: true},
+	{Field: colEpisodesAdapted, Headers: []string{"Episodes Adapted", "No. of Episodes", "# of Episodes"}},
+	{Field: colFillerEpisodes, Headers: []string{"Filler Episodes", "Filler"}},
+	{Field: colTimeSavedMins, Headers: []string{"Time Saved (mins)", "Time Saved (minutes)", "Time Saved"}},
+	{Field: colTimeSavedPercent, Headers: []string{"Time Saved (%)", "Time Saved %"}},
+	{Field: colAudioLanguages, Headers: []string{"Audio Languages", "Audio"}},
+	{Field: colSubtitleLanguages, Headers: []string{"Subtitle Languages", "Subtitles"}},
+	{Field: colResolution, Headers: []string{"Resolution", "Resolutions"}},
+	{Field: colSaga, Headers: []string{"Saga", "Sagas"}},
+	{Field: colOnePaceEpisodes, Headers: []string{"One Pace Episodes", "No. of One Pace Episodes", "Episodes (One Pace)", "# of One Pace Episodes"}},
+	{Field: colAnimeRuntime, Headers: []string{"Anime Runtime", "Runtime (Anime)", "Original Runtime", "Anime Length"}},
+	{Field: colOnePaceRuntime, Headers: []string{"One Pace Runtime", "Runtime (One Pace)", "One Pace Length"}},
+}
+
+// arcListLayoutTitle names the arc list in its SheetLayout.
+const arcListLayoutTitle = "Arc list"
+
+// fetchArcList reads the main Google Sheet HTML arc list, and its layout.
+func fetchArcList(spreadsheetID string) ([]model.Arc, model.SheetLayout, error) {
 	url := fmt.Sprintf("https://docs.google.com/spreadsheets/u/0/d/%s/htmlview/sheet?headers=true&gid=0", spreadsheetID)
 
 	fmt.Println("Launching Chrome...")
@@ -110,13 +177,13 @@ func fetchArcList(spreadsheetID string) ([]model.Arc, error) {
 		chromedp.OuterHTML("html", &html, chromedp.ByQuery),
 	)
 	if err != nil {
-		return nil, fmt.Errorf("chromedp: %w", err)
+		return nil, model.SheetLayout{}, fmt.Errorf("chromedp: %w", err)
 	}
 
 	// Parse using goquery (use a new reader)
 	doc, err := goquery.NewDocumentFromReader(strings.NewReader(html))
 	if err != nil {
-		return nil, fmt.Errorf("goquery: %w", err)
+		return nil, model.SheetLayout{}, fmt.Errorf("goquery: %w", err)
 	}
 
 	var arcs []model.Arc
@@ -132,29 +199,39 @@ func fetchArcList(spreadsheetID string) ([]model.Arc, error) {
 		rows = doc.Find("table.grid-container tr")
 	}
 	if rows.Length() == 0 {
-		return nil, fmt.Errorf("no rows found in sheet HTML")
+		return nil, model.SheetLayout{}, fmt.Errorf("no rows found in sheet HTML")
 	}
 
-	rows.Each(func(i int, row *goquery.Selection) {
+	headerRow, columns, err := findHeaderRow(rows, arcListColumns)
+	if err != nil {
+		return nil, model.SheetLayout{}, fmt.Errorf("arc list: %w", err)
+	}
+	cell := func(cells *goquery.Selection, field string) *goquery.Selection {
+		return sheetCell(cells, columns, field)
+	}
+	layout := sheetLayout(rows, headerRow, "0")
+	layout.Title = arcListLayoutTitle
+	// Columns with no field of their own go to Arc.Extra.
+	extraColumns := columns.Unmapped(layout.Header)
+
+	rows.Slice(headerRow+1, goquery.ToEnd).Each(func(_ int, row *goquery.Selection) {
 		cells := row.Find("td")
-		if cells.Length() < 2 {
-			return
-		}
 
-		rawArc := strings.TrimSpace(cells.Eq(0).Text())
-		if rawArc == "" || rawArc == "No." {
+		rawArc := strings.TrimSpace(cell(cells, colArcNumber).Text())
+		if rawArc == "" {
 			return
 		}
 		arcFloat, err := strconv.ParseFloat(rawArc, 64)
 
-		title := strings.TrimSpace(cells.Eq(1).Text())
-		if title == "" || title == "Arcs" {
+		titleCell := cell(cells, colArcTitle)
+		title := strings.TrimSpace(titleCell.Text())
+		if title == "" {
 			return
 		}
 
 		// Extract GID from <a href="#gid=1122135437">
 		gid := ""
-		cells.Eq(1).Find("a").Each(func(_ int, a *goquery.Selection) {
+		titleCell.Find("a").Each(func(_ int, a *goquery.Selection) {
 			if href, ok := a.Attr("href"); ok && strings.Contains(href, "gid=") {
 				parts := strings.Split(href, "gid=")
 				gid = parts[len(parts)-1]
@@ -177,26 +254,58 @@ func fetchArcList(spreadsheetID string) ([]model.Arc, error) {
 			status = "TBR"
 			cleanTitle = strings.TrimSpace(strings.ReplaceAll(title, "(TBR)", ""))
 		}
-		mangaChapters := strings.TrimSpace(cells.Eq(3).Text())
-		numberofChapters := strings.TrimSpace(cells.Eq(4).Text())
-		animeEpisodes := strings.TrimSpace(cells.Eq(5).Text())
-		episodesAdapted := strings.TrimSpace(cells.Eq(6).Text())
-		fillerEpisodes := strings.TrimSpace(cells.Eq(7).Text())
-		timeSavedMins := strings.TrimSpace(cells.Eq(11).Text())
-		timeSavedPercent := strings.TrimSpace(cells.Eq(12).Text())
-		audioLanguages := strings.TrimSpace(cells.Eq(13).Text())
-		subtitleLanguages := strings.TrimSpace(cells.Eq(14).Text())
-		resolution := strings.TrimSpace(cells.Eq(16).Text())
+		mangaChapters := strings.TrimSpace(cell(cells, colMangaChapters).Text())
+		numberofChapters := strings.TrimSpace(cell(cells, colNumberOfChapters).Text())
+		animeEpisodes := strings.TrimSpace(cell(cells, colAnimeEpisodes).Text())
+		episodesAdapted := strings.TrimSpace(cell(cells, colEpisodesAdapted).Text())
+		fillerEpisodes := strings.TrimSpace(cell(cells, colFillerEpisodes).Text())
+		timeSavedMins := strings.TrimSpace(cell(cells, colTimeSavedMins).Text())
+		timeSavedPercent := strings.TrimSpace(cell(cells, colTimeSavedPercent).Text())
+		audioLanguages := strings.TrimSpace(cell(cells, colAudioLanguages).Text())
+		subtitleLanguages := strings.TrimSpace(cell(cells, colSubtitleLanguages).Text())
+		resolution := strings.TrimSpace(cell(cells, colResolution).Text())
+		saga := strings.TrimSpace(cell(cells, colSaga).Text())
+		onePaceEpisodes := strings.TrimSpace(cell(cells, colOnePaceEpisodes).Text())
+		animeRuntime := strings.TrimSpace(cell(cells, colAnimeRuntime).Text())
+		onePaceRuntime := strings.TrimSpace(cell(cells, colOnePaceRuntime).Text())
+
+		var extra map[string]string
+		for i, name := range extraColumns {
+			if v := cleanText(cells.Eq(i).Text()); v != "" {
+				if extra == nil {
+					extra = make(map[string]string)
+				}
+				extra[name] = v
+			}
+		}
+
+		audioList, unknownAudio := parse.Languages(audioLanguages)
+		subtitleList, unknownSubs := parse.Languages(subtitleLanguages)
+		var languageWarnings []string
+		for _, tok := range unknownAudio {
+			languageWarnings = append(languageWarnings, "audio: unrecognised language "+strconv.Quote(tok))
+		}
+		for _, tok := range unknownSubs {
+			languageWarnings = append(languageWarnings, "subtitles: unrecognised language "+strconv.Quote(tok))
+		}
+		for _, w := range languageWarnings {
+			fmt.Printf("Warning: arc %q: %s\n", cleanTitle, w)
+		}
 
 		arcs = append(arcs, model.Arc{
 			ID:                    stableArcID(gid, cleanTitle),
-			Arc:                   int(arcFloat * 10),
+			Number:                rawArc,
+			SortKey:               arcFloat,
 			Title:                 cleanTitle,
 			Status:                status,
 			AudioLanguages:        audioLanguages,
 			SubtitleLanguages:     subtitleLanguages,
+			AudioLanguageList:     audioList,
+			SubtitleLanguageList:  subtitleList,
+			LanguageWarnings:      languageWarnings,
 			MangaChapters:         mangaChapters,
 			MangaChapterRange:     parse.ChapterRange(mangaChapters),
+			MangaChapterRefs:      parse.References(mangaChapters, model.SegmentMangaChapter),
 			NumberOfChapters:      numberofChapters,
 			EpisodesAdapted:       episodesAdapted,
 			FillerEpisodes:        fillerEpisodes,
@@ -206,12 +315,84 @@ func fetchArcList(spreadsheetID string) ([]model.Arc, error) {
 			TimeSavedPercentValue: parse.Percent(timeSavedPercent),
 			AnimeEpisodes:         animeEpisodes,
 			AnimeEpisodeRange:     parse.ChapterRange(animeEpisodes),
+			AnimeEpisodeRefs:      parse.References(animeEpisodes, model.SegmentAnimeEpisode),
 			Resolution:            resolution,
+			ResolutionList:        parse.Resolutions(resolution),
+			Saga:                  saga,
+			OnePaceEpisodes:       onePaceEpisodes,
+			OnePaceEpisodesValue:  parse.IntVal(onePaceEpisodes),
+			AnimeRuntime:          animeRuntime,
+			AnimeRuntimeSeconds:   parse.Runtime(animeRuntime),
+			OnePaceRuntime:        onePaceRuntime,
+			OnePaceRuntimeSeconds: parse.Runtime(onePaceRuntime),
 			GID:                   gid,
+			Extra:                 extra,
 		})
 
 	})
-	return arcs, nil
+	return arcs, layout, nil
+}
+
+// findHeaderRow returns the index of the first row of a sheet whose cells
+// map every required column, and that mapping. When no row does, the error
+// is the one for the row that came closest.
+func findHeaderRow(rows *goquery.Selection, columns []parse.Column) (int, parse.ColumnMap, error) {
+	headerRow, best := -1, -1
+	var found parse.ColumnMap
+	var bestErr error
+	rows.EachWithBreak(func(i int, row *goquery.Selection) bool {
+		m, err := parse.MapColumns(rowTexts(row), columns)
+		if err == nil {
+			headerRow, found = i, m
+			return false
+		}
+		if len(m) > best {
+			best, bestErr = len(m), err
+		}
+		return true
+	})
+	if headerRow < 0 {
+		if bestErr == nil {
+			bestErr = fmt.Errorf("no header row found")
+		}
+		return -1, nil, bestErr
+	}
+	return headerRow, found, nil
+}
+
+// rowTexts returns the cleaned text of each of a row's cells.
+func rowTexts(row *goquery.Selection) []string {
+	var texts []string
+	row.Find("td").Each(func(_ int, td *goquery.Selection) {
+		texts = append(texts, cleanText(td.Text()))
+	})
+	return texts
+}
+
+// sheetLayout fingerprints the layout of tab sheet: the header row's cells and
+// the widest row's cell count.
+func sheetLayout(rows *goquery.Selection, headerRow int, sheet string) model.SheetLayout {
+	columns := 0
+	rows.Each(func(_ int, row *goquery.Selection) {
+		columns = max(columns, row.Find("td").Length())
+	})
+	header := rowTexts(rows.Eq(headerRow))
+	return model.SheetLayout{
+		Sheet:       sheet,
+		Header:      header,
+		Columns:     columns,
+		Fingerprint: parse.LayoutFingerprint(header, columns),
+	}
+}
+
+// sheetCell returns the cell of a row mapped to field, or an empty
+// selection when the sheet has no such column (or the row is short).
+func sheetCell(cells *goquery.Selection, columns parse.ColumnMap, field string) *goquery.Selection {
+	i, ok := columns[field]
+	if !ok {
+		return cells.Slice(0, 0)
+	}
+	return cells.Eq(i)
 }
 
 // stableArcID returns a join key for an arc that survives normalizeArcIDs
@@ -246,45 +427,15 @@ func slugify(s string) string {
 	return strings.Trim(b.String(), "-")
 }
 
-// normalizeArcIDs fixes fractional arc numbers (6.5 → 7)
-// AND guarantees unique, sequential arc IDs.
+// normalizeArcIDs assigns sequential arc numbers (1, 2, 3, ...) in sheet
+// order, so an arc slotted in as "6.5" becomes 7 and the arcs after it
+// shift up. The sheet's own number stays in Number/SortKey.
 func normalizeArcIDs(arcs []model.Arc) []model.Arc {
-	if len(arcs) == 0 {
-		return arcs
-	}
-
 	normalized := make([]model.Arc, len(arcs))
-
-	nextID := 1
-	lastAssigned := 0
-
 	for i, arc := range arcs {
-
-		// Convert fractional decimals
-		raw := float64(arc.Arc) / 10.0
-
-		// Round fractional arc numbers UP (6.5 → 7)
-		var rounded int
-		if raw == float64(int(raw)) {
-			rounded = int(raw)
-		} else {
-			rounded = int(raw) + 1
-		}
-
-		// Ensure no duplicates:
-		// If rounded ≤ lastAssigned, bump it
-		if rounded <= lastAssigned {
-			rounded = lastAssigned + 1
-		}
-
-		// Assign sequential ID
 		normalized[i] = arc
-		normalized[i].Arc = nextID
-
-		lastAssigned = rounded
-		nextID++
+		normalized[i].Arc = i + 1
 	}
-
 	return normalized
 }
 
@@ -313,15 +464,13 @@ func extractEpisodeNumber(s string) int {
 	return n
 }
 
-// Convert "2025.05.03" → "2025-05-03"
-func convertDate(s string) string {
-	if strings.Contains(s, ".") {
-		parts := strings.Split(s, ".")
-		if len(parts) == 3 {
-			return fmt.Sprintf("%s-%s-%s", parts[0], parts[1], parts[2])
-		}
+// sheetRowNumber returns the row number the sheet shows for row (its row
+// header cell), or fallback when the HTML has none.
+func sheetRowNumber(row *goquery.Selection, fallback int) int {
+	if n, err := strconv.Atoi(cleanText(row.Find("th").First().Text())); err == nil {
+		return n
 	}
-	return strings.TrimSpace(s)
+	return fallback
 }
 
 // Extracts the real URL from a Google redirect href.
@@ -351,9 +500,59 @@ func extractURLFromHref(href string) string {
 	return href
 }
 
-// fetchArcEpisodes downloads & parses each arc’s episode guide.
-// fetchArcEpisodes parses the episode table for a specific arc.
-func fetchArcEpisodes(spreadsheetID, gid string) ([]model.Episode, error) {
+// Episode sheet fields (episodeSheetColumns).
+const (
+	colEpisodeName           = "name"
+	colEpisodeChapters       = "chapters"
+	colEpisodeAnimeEpisodes  = "anime_episodes"
+	colEpisodeReleased       = "released"
+	colEpisodeLength         = "length"
+	colEpisodeCRC32          = "crc32"
+	colEpisodeExtendedCRC32  = "extended_crc32"
+	colEpisodeExtendedLength = "extended_length"
+)
+
+// episodeSheetColumns maps an arc episode sheet's header names to fields,
+// like arcListColumns. Arcs differ: many have no extended cut columns, and
+// some have extra columns, which are ignored.
+var episodeSheetColumns = []parse.Column{
+	{Field: colEpisodeName, Headers: []string{"One Pace Episode", "Episode", "Title", "Name"}, Required: true},
+	{Field: colEpisodeChapters, Headers: []string{"Chapters", "Manga Chapters"}},
+	{Field: colEpisodeAnimeEpisodes, Headers: []string{"Episodes", "Anime Episodes"}},
+	{Field: colEpisodeReleased, Headers: []string{"Release Date", "Released", "Date"}},
+	{Field: colEpisodeLength, Headers: []string{"Length", "Runtime", "Duration"}},
+	{Field: colEpisodeCRC32, Headers: []string{"MKV CRC32", "CRC32", "CRC", "Standard CRC32"}},
+	{Field: colEpisodeExtendedCRC32, Headers: []string{"MKV CRC32 (Extended)", "Extended CRC32", "Extended CRC", "Extended"}},
+	{Field: colEpisodeExtendedLength, Headers: []string{"Length (Extended)", "Extended Length", "Extended Runtime"}},
+}
+
+// optionalEpisodeColumns are episode sheet columns whose absence isn't
+// worth a warning.
+var optionalEpisodeColumns = map[string]bool{
+	colEpisodeExtendedCRC32:  true,
+	colEpisodeExtendedLength: true,
+}
+
+// legacyEpisodeColumns is the fixed layout episode sheets used before they
+// were read by header: two title rows, then the name in column 1. Used
+// when a sheet's header row can't be found.
+var (
+	legacyEpisodeHeaderRow = 1
+	legacyEpisodeColumns   = parse.ColumnMap{
+		colEpisodeName:           1,
+		colEpisodeChapters:       2,
+		colEpisodeAnimeEpisodes:  3,
+		colEpisodeReleased:       4,
+		colEpisodeLength:         5,
+		colEpisodeCRC32:          6,
+		colEpisodeExtendedCRC32:  7,
+		colEpisodeExtendedLength: 8,
+	}
+)
+
+// fetchArcEpisodes parses the episode table and layout for a specific arc,
+// reporting rows it couldn't read (and missing columns) as warnings.
+func fetchArcEpisodes(spreadsheetID, gid string) ([]model.Episode, model.SheetLayout, []model.SheetWarning, error) {
 
 	sheetURL := fmt.Sprintf(
 		"https://docs.google.com/spreadsheets/u/0/d/%s/htmlview/sheet?headers=true&gid=%s",
@@ -377,12 +576,12 @@ func fetchArcEpisodes(spreadsheetID, gid string) ([]model.Episode, error) {
 		chromedp.OuterHTML("html", &html),
 	)
 	if err != nil {
-		return nil, fmt.Errorf("chromedp: %w", err)
+		return nil, model.SheetLayout{}, nil, fmt.Errorf("chromedp: %w", err)
 	}
 
 	doc, err := goquery.NewDocumentFromReader(strings.NewReader(html))
 	if err != nil {
-		return nil, fmt.Errorf("goquery: %w", err)
+		return nil, model.SheetLayout{}, nil, fmt.Errorf("goquery: %w", err)
 	}
 
 	rows := doc.Find("table.waffle tr")
@@ -390,33 +589,61 @@ func fetchArcEpisodes(spreadsheetID, gid string) ([]model.Episode, error) {
 		rows = doc.Find("table.ritz tr")
 	}
 	if rows.Length() == 0 {
-		return nil, fmt.Errorf("no rows found in sheet")
+		return nil, model.SheetLayout{}, nil, fmt.Errorf("no rows found in sheet")
 	}
 
-	var episodes []model.Episode
+	episodes, layout, warnings := parseArcEpisodes(rows, gid)
+	return episodes, layout, warnings, nil
+}
 
-	rows.Each(func(i int, row *goquery.Selection) {
+// parseArcEpisodes reads the episodes and layout from the rows of an arc's
+// episode sheet (tab gid).
+func parseArcEpisodes(rows *goquery.Selection, gid string) ([]model.Episode, model.SheetLayout, []model.SheetWarning) {
+	var episodes []model.Episode
+	var warnings []model.SheetWarning
+	warn := func(row int, field, value, message string) {
+		warnings = append(warnings, model.SheetWarning{Sheet: gid, Row: row, Field: field, Value: value, Message: message})
+	}
 
-		if i < 2 {
-			return
+	headerRow, columns, err := findHeaderRow(rows, episodeSheetColumns)
+	if err != nil {
+		warn(0, "", "", fmt.Sprintf("no header row (%v); assuming the standard column layout", err))
+		headerRow, columns = legacyEpisodeHeaderRow, legacyEpisodeColumns
+	}
+	for _, col := range episodeSheetColumns {
+		if _, ok := columns[col.Field]; !ok && !optionalEpisodeColumns[col.Field] {
+			warn(0, col.Field, "", fmt.Sprintf("no %q column", col.Headers[0]))
 		}
+	}
+	cell := func(cells *goquery.Selection, field string) *goquery.Selection {
+		return sheetCell(cells, columns, field)
+	}
+	layout := sheetLayout(rows, headerRow, gid)
 
-		cells := row.Find("td")
-		if cells.Length() < 7 {
-			return
-		}
+	seen := make(map[int]int) // episode number -> sheet row
+	rows.Slice(headerRow+1, goquery.ToEnd).Each(func(i int, row *goquery.Selection) {
+		sheetRow := sheetRowNumber(row, headerRow+i+2)
 
-		epName := cleanText(cells.Eq(1).Text())
+		cells := row.Find("td")
+		epName := cleanText(cell(cells, colEpisodeName).Text())
 		if epName == "" {
+			if crc, _ := crcCell(cell(cells, colEpisodeCRC32)); crc != "" {
+				warn(sheetRow, colEpisodeName, "", "CRC32 "+crc+" without an episode name; row skipped")
+			}
 			return
 		}
 
+		// Specials and single-episode arcs have no number; they're episode 0.
 		epNum := extractEpisodeNumber(epName)
+		if prev, dup := seen[epNum]; dup {
+			warn(sheetRow, colEpisodeName, epName, fmt.Sprintf("episode %d already on row %d", epNum, prev))
+		}
+		seen[epNum] = sheetRow
 
-		chapters := cleanText(cells.Eq(2).Text())
-		animeEps := cleanText(cells.Eq(3).Text())
-		releaseDate := convertDate(cleanText(cells.Eq(4).Text()))
-		length := cleanText(cells.Eq(5).Text())
+		chapters := cleanText(cell(cells, colEpisodeChapters).Text())
+		animeEps := cleanText(cell(cells, colEpisodeAnimeEpisodes).Text())
+		releaseDate, _ := parse.Date(cleanText(cell(cells, colEpisodeReleased).Text()))
+		length := cleanText(cell(cells, colEpisodeLength).Text())
 
 		var files model.EpisodeFileVariants
 		var hasExtended bool
@@ -424,21 +651,12 @@ func fetchArcEpisodes(spreadsheetID, gid string) ([]model.Episode, error) {
 		// ─────────────────────────────────────
 		// NORMAL VERSION
 		// ─────────────────────────────────────
-		var crc32 string
-		var url string
-
-		cells.Eq(6).Find("a").Each(func(_ int, a *goquery.Selection) {
-			crc32 = cleanText(a.Text())
-			if href, ok := a.Attr("href"); ok {
-				url = extractURLFromHref(href)
-			}
-		})
-
-		// The sheet no longer hyperlinks every CRC — fall back to the
-		// cell's plain text when it looks like a CRC32.
+		// Unreleased episodes hold the same "TBR"-style placeholders as
+		// their release date; anything else that isn't a CRC is reported.
+		crc32, url := crcCell(cell(cells, colEpisodeCRC32))
 		if crc32 == "" {
-			if txt := cleanText(cells.Eq(6).Text()); crcRe.MatchString(txt) {
-				crc32 = txt
+			if txt := cleanText(cell(cells, colEpisodeCRC32).Text()); !parse.IsDatePlaceholder(txt) {
+				warn(sheetRow, colEpisodeCRC32, txt, "not a CRC32")
 			}
 		}
 
@@ -455,51 +673,56 @@ func fetchArcEpisodes(spreadsheetID, gid string) ([]model.Episode, error) {
 		// ─────────────────────────────────────
 		// EXTENDED VERSION
 		// ─────────────────────────────────────
-		if cells.Length() >= 8 {
-
-			var crcExt, urlExt string
-			extLength := ""
-
-			cells.Eq(7).Find("a").Each(func(_ int, a *goquery.Selection) {
-				crcExt = cleanText(a.Text())
-				if href, ok := a.Attr("href"); ok {
-					urlExt = extractURLFromHref(href)
-				}
-			})
-
-			if crcExt == "" {
-				if txt := cleanText(cells.Eq(7).Text()); crcRe.MatchString(txt) {
-					crcExt = txt
-				}
-			}
-
-			if cells.Length() >= 9 {
-				extLength = cleanText(cells.Eq(8).Text())
+		crcExt, urlExt := crcCell(cell(cells, colEpisodeExtendedCRC32))
+		if crcExt == "" {
+			if txt := cleanText(cell(cells, colEpisodeExtendedCRC32).Text()); !parse.IsDatePlaceholder(txt) {
+				warn(sheetRow, colEpisodeExtendedCRC32, txt, "not a CRC32")
 			}
-
-			if crcExt != "" {
-				hasExtended = true
-				files.Extended = &model.EpisodeFile{
-					Version:       "extended",
-					CRC32:         crcExt,
-					Length:        extLength,
-					LengthSeconds: parse.LengthSeconds(extLength),
-					URL:           urlExt,
-				}
+		}
+		extLength := cleanText(cell(cells, colEpisodeExtendedLength).Text())
+
+		if crcExt != "" {
+			hasExtended = true
+			files.Extended = &model.EpisodeFile{
+				Version:       "extended",
+				CRC32:         crcExt,
+				Length:        extLength,
+				LengthSeconds: parse.LengthSeconds(extLength),
+				URL:           urlExt,
 			}
 		}
 
 		episodes = append(episodes, model.Episode{
-			Episode:      epNum,
-			Title:        epName,
-			Chapters:     chapters,
-			ChapterRange: parse.ChapterRange(chapters),
-			AnimeEps:     animeEps,
-			Released:     releaseDate,
-			HasExtended:  hasExtended,
-			Files:        files,
+			Episode:          epNum,
+			Title:            epName,
+			Chapters:         chapters,
+			ChapterRange:     parse.ChapterRange(chapters),
+			ChapterRefs:      parse.References(chapters, model.SegmentMangaChapter),
+			AnimeEps:         animeEps,
+			AnimeEpisodeRefs: parse.References(animeEps, model.SegmentAnimeEpisode),
+			Released:         releaseDate,
+			HasExtended:      hasExtended,
+			Files:            files,
 		})
 	})
 
-	return episodes, nil
+	return episodes, layout, warnings
+}
+
+// crcCell reads a CRC32 cell: the hyperlinked CRC and the (decoded) link,
+// or, since the sheet no longer hyperlinks every CRC, the cell's plain
+// text when it looks like a CRC32. Returns "" when the cell holds neither.
+func crcCell(c *goquery.Selection) (crc32, url string) {
+	c.Find("a").Each(func(_ int, a *goquery.Selection) {
+		crc32 = cleanText(a.Text())
+		if href, ok := a.Attr("href"); ok {
+			url = extractURLFromHref(href)
+		}
+	})
+	if crc32 == "" {
+		if txt := cleanText(c.Text()); crcRe.MatchString(txt) {
+			crc32 = txt
+		}
+	}
+	return crc32, url
 }
@@ -0,0 +1,152 @@
+package fetch
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+
+	"metadata-service/internal/model"
+)
+
+// sheetRows renders rows of cells the way Google's htmlview does, with
+// each row's sheet number in a leading <th>, and returns the parsed rows.
+func sheetRows(t *testing.T, rows ...[]string) *goquery.Selection {
+	t.Helper()
+	var b strings.Builder
+	b.WriteString(`<table class="waffle"><tbody>`)
+	for i, row := range rows {
+		fmt.Fprintf(&b, `<tr><th>%d</th>`, i+1)
+		for _, cell := range row {
+			fmt.Fprintf(&b, `<td>%s</td>`, cell)
+		}
+		b.WriteString(`</tr>`)
+	}
+	b.WriteString(`</tbody></table>`)
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(b.String()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return doc.Find("table.waffle tr")
+}
+
+func warningMessages(warnings []model.SheetWarning) []string {
+	var out []string
+	for _, w := range warnings {
+		out = append(out, fmt.Sprintf("%d %s %q: %s", w.Row, w.Field, w.Value, w.Message))
+	}
+	return out
+}
+
+func TestParseArcEpisodesWithoutExtendedColumns(t *testing.T) {
+	rows := sheetRows(t,
+		[]string{"Romance Dawn"},
+		[]string{"One Pace Episode", "Chapters", "Episodes", "Release Date", "Length", "MKV CRC32"},
+		[]string{"Romance Dawn 01", "1-3", "Ep. 1", "2020-01-01", "20:00",
+			`<a href="https://www.google.com/url?q=https://nyaa.si/view/1&amp;sa=D">AAAAAAAA</a>`},
+		[]string{"Romance Dawn 02", "4-6", "Ep. 2", "TBR", "", "TBR"},
+	)
+
+	episodes, layout, warnings := parseArcEpisodes(rows, "123")
+	if len(warnings) != 0 {
+		t.Errorf("warnings = %v", warningMessages(warnings))
+	}
+	if len(episodes) != 2 {
+		t.Fatalf("got %d episodes, want 2", len(episodes))
+	}
+	ep := episodes[0]
+	if ep.Episode != 1 || ep.Chapters != "1-3" || ep.AnimeEps != "Ep. 1" || !ep.Released.Known() || ep.HasExtended {
+		t.Errorf("episode 1 = %+v", ep)
+	}
+	if f := ep.Files.Normal; f == nil || f.CRC32 != "AAAAAAAA" || f.URL != "https://nyaa.si/view/1" || f.LengthSeconds != 1200 {
+		t.Errorf("episode 1 file = %+v", f)
+	}
+	if ep.ChapterRefs == nil || ep.AnimeEpisodeRefs == nil {
+		t.Error("episode 1 references not parsed")
+	}
+	if episodes[1].Files.Normal != nil {
+		t.Errorf("unreleased episode has a file: %+v", episodes[1].Files.Normal)
+	}
+	if layout.Sheet != "123" || layout.Columns != 6 || len(layout.Header) != 6 || layout.Header[5] != "MKV CRC32" || layout.Fingerprint == "" {
+		t.Errorf("layout = %+v", layout)
+	}
+}
+
+func TestParseArcEpisodesReorderedColumns(t *testing.T) {
+	// CRC32 columns first, an unknown "Notes" column, and other header
+	// names for the same fields.
+	rows := sheetRows(t,
+		[]string{"Extended CRC32", "MKV CRC32", "Notes", "Title", "Manga Chapters", "Anime Episodes", "Released", "Runtime", "Extended Length"},
+		[]string{"BBBBBBBB", "AAAAAAAA", "re-edited", "Drum Island 03", "140-142", "Ep. 84", "2021-05-01", "25:00", "31:00"},
+	)
+
+	episodes, _, warnings := parseArcEpisodes(rows, "456")
+	if len(warnings) != 0 {
+		t.Errorf("warnings = %v", warningMessages(warnings))
+	}
+	if len(episodes) != 1 {
+		t.Fatalf("got %d episodes, want 1", len(episodes))
+	}
+	ep := episodes[0]
+	if ep.Episode != 3 || ep.Title != "Drum Island 03" || ep.Chapters != "140-142" || ep.AnimeEps != "Ep. 84" {
+		t.Errorf("episode = %+v", ep)
+	}
+	if ep.Files.Normal == nil || ep.Files.Normal.CRC32 != "AAAAAAAA" || ep.Files.Normal.Length != "25:00" {
+		t.Errorf("normal file = %+v", ep.Files.Normal)
+	}
+	if !ep.HasExtended || ep.Files.Extended == nil || ep.Files.Extended.CRC32 != "BBBBBBBB" || ep.Files.Extended.Length != "31:00" {
+		t.Errorf("extended file = %+v", ep.Files.Extended)
+	}
+}
+
+func TestParseArcEpisodesLegacyLayout(t *testing.T) {
+	// No recognisable header row: two title rows, then the fixed columns
+	// with the name in column 1.
+	rows := sheetRows(t,
+		[]string{"", "Alabasta"},
+		[]string{"", "Ep", "Ch", "Anime", "Date", "Len", "Std", "Ext", "Ext len"},
+		[]string{"", "Alabasta 01", "155-157", "Ep. 92", "2019-03-01", "22:00", "CCCCCCCC", "", ""},
+	)
+
+	episodes, layout, warnings := parseArcEpisodes(rows, "789")
+	if len(warnings) != 1 || warnings[0].Row != 0 || !strings.Contains(warnings[0].Message, "no header row") {
+		t.Errorf("warnings = %v, want just the missing header row", warningMessages(warnings))
+	}
+	if len(episodes) != 1 || episodes[0].Episode != 1 || episodes[0].Chapters != "155-157" ||
+		episodes[0].Files.Normal == nil || episodes[0].Files.Normal.CRC32 != "CCCCCCCC" {
+		t.Fatalf("episodes = %+v", episodes)
+	}
+	if len(layout.Header) == 0 || layout.Header[1] != "Ep" {
+		t.Errorf("layout header = %v, want the legacy header row", layout.Header)
+	}
+}
+
+func TestParseArcEpisodesWarnings(t *testing.T) {
+	rows := sheetRows(t,
+		[]string{"One Pace Episode", "Chapters", "Episodes", "Release Date", "Length", "MKV CRC32"},
+		[]string{"Wano 01", "909-911", "Ep. 890", "2022-01-01", "25:00", "DDDDDDDD"},
+		[]string{"", "", "", "", "", "EEEEEEEE"},
+		[]string{"Wano 01", "912", "Ep. 891", "2022-02-01", "24:00", "FFFFFFFF"},
+		[]string{"Wano 02", "913", "Ep. 892", "2022-03-01", "24:00", "pending QC"},
+	)
+
+	episodes, _, warnings := parseArcEpisodes(rows, "42")
+	if len(episodes) != 3 {
+		t.Errorf("got %d episodes, want 3 (the nameless row skipped)", len(episodes))
+	}
+	want := []string{
+		`3 name "": CRC32 EEEEEEEE without an episode name; row skipped`,
+		`4 name "Wano 01": episode 1 already on row 2`,
+		`5 crc32 "pending QC": not a CRC32`,
+	}
+	got := warningMessages(warnings)
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("warnings =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+	for _, w := range warnings {
+		if w.Sheet != "42" {
+			t.Errorf("warning %+v not attributed to the tab", w)
+		}
+	}
+}
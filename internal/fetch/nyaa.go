@@ -1,55 +1,228 @@
 package fetch
 
 import (
+	"encoding/json"
 	"encoding/xml"
+	"errors"
 	"fmt"
+	"io/fs"
 	"net/http"
 	"net/url"
+	"os"
+	"path/filepath"
 	"strings"
 	"time"
+
+	"metadata-service/internal/config"
+	"metadata-service/internal/model"
+	"metadata-service/internal/parse"
+	"metadata-service/internal/util"
 )
 
 // nyaaRSS models the subset of the Nyaa RSS feed we care about.
 type nyaaRSS struct {
 	Channel struct {
-		Items []struct {
-			Title string `xml:"title"`
-			GUID  string `xml:"guid"`
-		} `xml:"item"`
+		Items []nyaaItem `xml:"item"`
 	} `xml:"channel"`
 }
 
+// nyaaItem is one search result. Seeders and the rest are in Nyaa's own
+// namespace.
+type nyaaItem struct {
+	Title     string `xml:"title"`
+	Link      string `xml:"link"` // the .torrent download
+	GUID      string `xml:"guid"` // the view page
+	PubDate   string `xml:"pubDate"`
+	Seeders   int    `xml:"https://nyaa.si/xmlns/nyaa seeders"`
+	Leechers  int    `xml:"https://nyaa.si/xmlns/nyaa leechers"`
+	Downloads int    `xml:"https://nyaa.si/xmlns/nyaa downloads"`
+	InfoHash  string `xml:"https://nyaa.si/xmlns/nyaa infoHash"`
+	Size      string `xml:"https://nyaa.si/xmlns/nyaa size"`
+}
+
 var nyaaHTTP = &http.Client{Timeout: 15 * time.Second}
 
-// ResolveNyaaURL looks up a One Pace release on Nyaa by its CRC32 and returns
-// the torrent view URL, or "" if it can't be found. The episode guide sheet
-// used to hyperlink every CRC to its Nyaa page but no longer does, so this
-// recovers the download URL for newly released episodes.
-func ResolveNyaaURL(crc32 string) string {
-	q := url.QueryEscape(`"One Pace" ` + crc32)
-	resp, err := nyaaHTTP.Get("https://nyaa.si/?page=rss&q=" + q)
+// nyaaSearchURL is Nyaa's RSS search, the query appended. Tests point it
+// at a local server.
+var nyaaSearchURL = "https://nyaa.si/?page=rss&q="
+
+// LookupNyaa searches Nyaa's RSS feed for a One Pace release by its CRC32.
+// The episode guide sheet used to hyperlink every CRC to its Nyaa page but
+// no longer does, so this recovers the download links for newly released
+// episodes the releases feed doesn't list. Returns nil, nil when Nyaa has
+// no torrent with the CRC in its title. Use a NyaaCache rather than
+// calling this directly.
+func LookupNyaa(crc32 string) (*model.NyaaTorrent, error) {
+	// Require the CRC to appear in the release title so a fuzzy search
+	// match can't attach the wrong torrent.
+	t, err := searchNyaa(`"One Pace" `+crc32, func(item nyaaItem) bool {
+		return strings.Contains(strings.ToUpper(item.Title), strings.ToUpper(crc32))
+	})
+	if t != nil && t.CRC32 == "" {
+		t.CRC32 = strings.ToUpper(crc32)
+	}
+	return t, err
+}
+
+// LookupNyaaInfoHash finds a torrent on Nyaa by its (hex) infohash, for
+// archive entries that only kept a search link. Returns nil, nil when
+// Nyaa doesn't have it.
+func LookupNyaaInfoHash(infoHash string) (*model.NyaaTorrent, error) {
+	return searchNyaa(infoHash, func(item nyaaItem) bool {
+		return strings.EqualFold(strings.TrimSpace(item.InfoHash), infoHash)
+	})
+}
+
+// searchNyaa runs a Nyaa RSS search and returns the first result that
+// matches.
+func searchNyaa(query string, match func(nyaaItem) bool) (*model.NyaaTorrent, error) {
+	resp, err := nyaaHTTP.Get(nyaaSearchURL + url.QueryEscape(query))
 	if err != nil {
-		fmt.Printf("Warning: nyaa lookup for %s failed: %v\n", crc32, err)
-		return ""
+		return nil, err
 	}
 	defer resp.Body.Close()
 	if resp.StatusCode != http.StatusOK {
-		fmt.Printf("Warning: nyaa lookup for %s: status %d\n", crc32, resp.StatusCode)
-		return ""
+		return nil, fmt.Errorf("status %d", resp.StatusCode)
 	}
 
 	var feed nyaaRSS
 	if err := xml.NewDecoder(resp.Body).Decode(&feed); err != nil {
-		fmt.Printf("Warning: nyaa lookup for %s: parse: %v\n", crc32, err)
-		return ""
+		return nil, fmt.Errorf("parse: %w", err)
 	}
-
-	// Require the CRC to appear in the release title so a fuzzy search
-	// match can't attach the wrong torrent.
 	for _, item := range feed.Channel.Items {
-		if strings.Contains(strings.ToUpper(item.Title), strings.ToUpper(crc32)) {
-			return item.GUID
+		if match(item) {
+			return nyaaTorrent(item), nil
 		}
 	}
-	return ""
+	return nil, nil
+}
+
+// nyaaTorrent converts a feed item, taking the CRC32 from the title. Nyaa's
+// feed has no magnet, so a compact one is built from the infohash and
+// title.
+func nyaaTorrent(item nyaaItem) *model.NyaaTorrent {
+	published, _ := parse.Timestamp(item.PubDate)
+	infoHash := strings.ToLower(strings.TrimSpace(item.InfoHash))
+	t := &model.NyaaTorrent{
+		Title:       strings.TrimSpace(item.Title),
+		ViewURL:     strings.TrimSpace(item.GUID),
+		TorrentURL:  strings.TrimSpace(item.Link),
+		InfoHash:    infoHash,
+		Size:        strings.TrimSpace(item.Size),
+		SizeBytes:   parse.ByteSize(item.Size),
+		PublishedAt: published,
+		Seeders:     item.Seeders,
+		Leechers:    item.Leechers,
+		Downloads:   item.Downloads,
+	}
+	if f := parse.ReleaseFilename(t.Title); f != nil {
+		t.CRC32 = f.CRC32
+	}
+	if infoHash != "" {
+		t.MagnetURI = "magnet:?xt=urn:btih:" + infoHash + "&dn=" + url.QueryEscape(t.Title)
+	}
+	return t
+}
+
+// nyaaCacheEntry is one lookup's result. A nil Torrent records that
+// Nyaa had nothing for it.
+type nyaaCacheEntry struct {
+	CheckedAt time.Time          `json:"checked_at"`
+	Torrent   *model.NyaaTorrent `json:"torrent,omitempty"`
+}
+
+// NyaaCache keeps LookupNyaa and LookupNyaaInfoHash results on disk, so
+// scheduled runs don't search Nyaa for the same CRCs every time. Results
+// are reused for config.NyaaCacheTTL, misses for
+// config.NyaaNegativeCacheTTL; failed lookups aren't cached.
+type NyaaCache struct {
+	path    string
+	entries map[string]nyaaCacheEntry
+	changed bool
+	now     func() time.Time
+}
+
+// LoadNyaaCache reads the cache at path. A missing file is an empty cache.
+func LoadNyaaCache(path string) (*NyaaCache, error) {
+	c := &NyaaCache{path: path, entries: map[string]nyaaCacheEntry{}, now: time.Now}
+	raw, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return c, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(raw, &c.entries); err != nil {
+		return nil, fmt.Errorf("decode %s: %w", path, err)
+	}
+	return c, nil
+}
+
+// Lookup returns the Nyaa torrent for a CRC32, from the cache while the
+// entry is fresh and from Nyaa otherwise. Returns nil when Nyaa has none
+// or can't be reached (with a warning).
+func (c *NyaaCache) Lookup(crc32 string) *model.NyaaTorrent {
+	return c.lookup(strings.ToUpper(crc32), func() (*model.NyaaTorrent, error) {
+		return LookupNyaa(crc32)
+	})
+}
+
+// LookupInfoHash is Lookup by infohash (hex), see LookupNyaaInfoHash.
+func (c *NyaaCache) LookupInfoHash(infoHash string) *model.NyaaTorrent {
+	infoHash = strings.ToLower(infoHash)
+	return c.lookup("btih:"+infoHash, func() (*model.NyaaTorrent, error) {
+		return LookupNyaaInfoHash(infoHash)
+	})
+}
+
+// lookup returns the cached result under key while it's fresh, else asks
+// search and caches its answer.
+func (c *NyaaCache) lookup(key string, search func() (*model.NyaaTorrent, error)) *model.NyaaTorrent {
+	if e, ok := c.entries[key]; ok && !e.expired(c.now()) {
+		return e.Torrent
+	}
+
+	t, err := search()
+	if err != nil {
+		fmt.Printf("Warning: nyaa lookup for %s failed: %v\n", key, err)
+		return nil
+	}
+	c.entries[key] = nyaaCacheEntry{CheckedAt: c.now().UTC(), Torrent: t}
+	c.changed = true
+	return t
+}
+
+// Save writes the cache back, dropping expired entries, if anything
+// changed.
+func (c *NyaaCache) Save() error {
+	now := c.now()
+	for crc, e := range c.entries {
+		if e.expired(now) {
+			delete(c.entries, crc)
+			c.changed = true
+		}
+	}
+	if !c.changed {
+		return nil
+	}
+	if err := util.EnsureDir(filepath.Dir(c.path)); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(c.entries, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(c.path, data, 0644); err != nil {
+		return err
+	}
+	c.changed = false
+	return nil
+}
+
+func (e nyaaCacheEntry) expired(now time.Time) bool {
+	ttl := config.NyaaCacheTTL
+	if e.Torrent == nil {
+		ttl = config.NyaaNegativeCacheTTL
+	}
+	return now.Sub(e.CheckedAt) > ttl
 }
@@ -0,0 +1,146 @@
+package fetch
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"metadata-service/internal/config"
+)
+
+// nyaaServer serves Nyaa RSS searches: a result for any query containing
+// a key of found, an empty feed otherwise, and a 503 for "fail". It counts
+// the searches it answered.
+func nyaaServer(t *testing.T, found map[string]string) *int {
+	t.Helper()
+	searches := new(int)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*searches++
+		q := r.URL.Query().Get("q")
+		if strings.Contains(q, "fail") {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprint(w, `<rss xmlns:nyaa="https://nyaa.si/xmlns/nyaa"><channel>`)
+		for key, infoHash := range found {
+			if strings.Contains(strings.ToUpper(q), strings.ToUpper(key)) {
+				fmt.Fprintf(w, `<item><title>[One Pace][1] Romance Dawn 01 [1080p][%s].mkv</title>`+
+					`<link>https://nyaa.si/download/1.torrent</link><guid>https://nyaa.si/view/1</guid>`+
+					`<pubDate>Mon, 26 Sep 2022 12:00:00 -0000</pubDate><nyaa:seeders>12</nyaa:seeders>`+
+					`<nyaa:infoHash>%s</nyaa:infoHash><nyaa:size>1.2 GiB</nyaa:size></item>`, key, infoHash)
+			}
+		}
+		fmt.Fprint(w, `</channel></rss>`)
+	}))
+	t.Cleanup(srv.Close)
+	prev := nyaaSearchURL
+	nyaaSearchURL = srv.URL + "/?page=rss&q="
+	t.Cleanup(func() { nyaaSearchURL = prev })
+	return searches
+}
+
+func TestNyaaCache(t *testing.T) {
+	searches := nyaaServer(t, map[string]string{"AAAAAAAA": "abcdef"})
+	path := filepath.Join(t.TempDir(), "cache", "nyaa.json")
+
+	// A missing file is an empty cache.
+	c, err := LoadNyaaCache(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clock := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	c.now = func() time.Time { return clock }
+
+	hit := c.Lookup("aaaaaaaa")
+	if hit == nil || hit.CRC32 != "AAAAAAAA" || hit.ViewURL != "https://nyaa.si/view/1" || hit.Seeders != 12 ||
+		hit.MagnetURI == "" || hit.SizeBytes == nil {
+		t.Fatalf("Lookup = %+v", hit)
+	}
+	if c.Lookup("BBBBBBBB") != nil {
+		t.Error("Lookup of a CRC Nyaa doesn't have returned a torrent")
+	}
+	if c.Lookup("fail") != nil || c.LookupInfoHash("fail") != nil {
+		t.Error("a failed search returned a torrent")
+	}
+	if *searches != 4 {
+		t.Errorf("%d searches, want 4", *searches)
+	}
+
+	// Hits and misses are reused from the cache, failures are retried.
+	c.Lookup("AAAAAAAA")
+	c.Lookup("BBBBBBBB")
+	c.Lookup("fail")
+	if *searches != 5 {
+		t.Errorf("%d searches after repeating them, want 5 (only the failure retried)", *searches)
+	}
+
+	// Misses expire after the negative TTL, hits only after the full one.
+	clock = clock.Add(config.NyaaNegativeCacheTTL + time.Hour)
+	c.Lookup("AAAAAAAA")
+	c.Lookup("BBBBBBBB")
+	if *searches != 6 {
+		t.Errorf("%d searches after the negative TTL, want 6 (only the miss refreshed)", *searches)
+	}
+
+	if err := c.Save(); err != nil {
+		t.Fatal(err)
+	}
+	saved, err := LoadNyaaCache(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(saved.entries) != 2 || saved.entries["AAAAAAAA"].Torrent == nil || saved.entries["BBBBBBBB"].Torrent != nil {
+		t.Errorf("saved entries = %+v, want the hit and the miss", saved.entries)
+	}
+	if _, ok := saved.entries["FAIL"]; ok {
+		t.Error("failed lookup was cached")
+	}
+
+	clock = clock.Add(config.NyaaCacheTTL)
+	c.Lookup("AAAAAAAA")
+	if *searches != 7 {
+		t.Errorf("%d searches after the TTL, want 7", *searches)
+	}
+
+	// Save drops entries that have since expired: the miss, but not the
+	// refreshed hit.
+	if err := c.Save(); err != nil {
+		t.Fatal(err)
+	}
+	if saved, err = LoadNyaaCache(path); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := saved.entries["BBBBBBBB"]; ok || len(saved.entries) != 1 {
+		t.Errorf("saved entries = %+v, want only the unexpired hit", saved.entries)
+	}
+}
+
+func TestNyaaCacheLookupInfoHash(t *testing.T) {
+	searches := nyaaServer(t, map[string]string{"abcdef": "ABCDEF"})
+	c, err := LoadNyaaCache(filepath.Join(t.TempDir(), "nyaa.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if hit := c.LookupInfoHash("ABCDEF"); hit == nil || hit.InfoHash != "abcdef" {
+		t.Fatalf("LookupInfoHash = %+v", hit)
+	}
+	if hit := c.LookupInfoHash("abcdef"); hit == nil || *searches != 1 {
+		t.Errorf("second lookup = %+v after %d searches, want the cached hit", hit, *searches)
+	}
+}
+
+func TestLoadNyaaCacheCorrupt(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nyaa.json")
+	if err := os.WriteFile(path, []byte("not json"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadNyaaCache(path); err == nil {
+		t.Error("corrupt cache loaded without an error")
+	}
+}
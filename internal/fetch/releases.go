@@ -1,17 +1,23 @@
 package fetch
 
 import (
+	"encoding/json"
 	"encoding/xml"
+	"errors"
 	"fmt"
+	"io/fs"
 	"net/http"
 	"net/url"
-	"regexp"
+	"os"
+	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/PuerkitoBio/goquery"
 
 	"metadata-service/internal/model"
 	"metadata-service/internal/parse"
+	"metadata-service/internal/util"
 )
 
 // onePaceReleasesFeed is the official releases feed for onepace.net. It's a
@@ -19,9 +25,11 @@ import (
 // release history, keyed by BitTorrent infoHash.
 const onePaceReleasesFeed = "https://onepace.net/en/releases/atom.xml"
 
-// dnCRCRe pulls the trailing CRC32 bracket out of a release filename, e.g.
-// "[One Pace][1011-1012] Wano 61 [1080p][1EF3F26C].mkv" → "1EF3F26C".
-var dnCRCRe = regexp.MustCompile(`\[([0-9A-Fa-f]{8})\](?:\.\w+)?$`)
+// maxFeedPages bounds how many pages FetchReleases follows looking for a
+// release it already knows.
+const maxFeedPages = 50
+
+var feedHTTP = &http.Client{Timeout: 30 * time.Second}
 
 //
 // ===== ATOM FEED SHAPE =====
@@ -29,6 +37,7 @@ var dnCRCRe = regexp.MustCompile(`\[([0-9A-Fa-f]{8})\](?:\.\w+)?$`)
 
 type atomFeed struct {
 	XMLName xml.Name    `xml:"feed"`
+	Links   []atomLink  `xml:"link"` // rel="next" for an older page (RFC 5005)
 	Entries []atomEntry `xml:"entry"`
 }
 
@@ -58,48 +67,180 @@ type atomContent struct {
 // ===== PUBLIC ENTRY =====
 //
 
-// FetchReleases downloads and parses the onepace.net releases feed.
-func FetchReleases() ([]model.Release, error) {
-	resp, err := http.Get(onePaceReleasesFeed)
+// ErrNotModified is returned by FetchReleases when the feed hasn't changed
+// since the validators were recorded.
+var ErrNotModified = errors.New("releases feed not modified")
+
+// FeedValidators are the releases feed's ETag and Last-Modified headers
+// from the last run that read it, sent back as If-None-Match and
+// If-Modified-Since so an unchanged feed costs a 304.
+type FeedValidators struct {
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"last_modified,omitempty"`
+}
+
+// LoadFeedValidators reads validators saved by FeedValidators.Save. A
+// missing file means none.
+func LoadFeedValidators(path string) (FeedValidators, error) {
+	var v FeedValidators
+	raw, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return v, nil
+	}
 	if err != nil {
-		return nil, fmt.Errorf("fetch releases feed: %w", err)
+		return v, err
+	}
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return v, fmt.Errorf("decode %s: %w", path, err)
 	}
-	defer resp.Body.Close()
+	return v, nil
+}
+
+// Save writes the validators to path. Only save them once the releases
+// they came with are safely exported, or the next run's 304 would skip
+// them.
+func (v FeedValidators) Save(path string) error {
+	if err := util.EnsureDir(filepath.Dir(path)); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// FetchReleases downloads and parses the onepace.net releases feed,
+// conditionally on prev: when the feed is unchanged it returns
+// ErrNotModified. Only entries known doesn't report (by infohash; pass nil
+// on a first run) are parsed in full; known ones come back with just their
+// title and category, the one thing the feed changes after publishing.
+// The feed is newest first, so while a page has nothing known and links
+// to an older page, that page is read too. The returned validators are
+// the feed's current ones.
+func FetchReleases(prev FeedValidators, known func(infoHash string) bool) ([]model.Release, FeedValidators, error) {
+	return fetchReleasesFrom(onePaceReleasesFeed, prev, known)
+}
+
+func fetchReleasesFrom(feedURL string, prev FeedValidators, known func(infoHash string) bool) ([]model.Release, FeedValidators, error) {
+	var releases []model.Release
+	next := prev
+	pageURL := feedURL
+	for page := 0; pageURL != ""; page++ {
+		if page == maxFeedPages {
+			fmt.Printf("Warning: releases feed: stopped after %d pages without reaching a known release\n", page)
+			break
+		}
+
+		req, err := http.NewRequest(http.MethodGet, pageURL, nil)
+		if err != nil {
+			return nil, prev, fmt.Errorf("fetch releases feed: %w", err)
+		}
+		// Validators only describe the first page.
+		if page == 0 {
+			if prev.ETag != "" {
+				req.Header.Set("If-None-Match", prev.ETag)
+			}
+			if prev.LastModified != "" {
+				req.Header.Set("If-Modified-Since", prev.LastModified)
+			}
+		}
+		resp, err := feedHTTP.Do(req)
+		if err != nil {
+			return nil, prev, fmt.Errorf("fetch releases feed: %w", err)
+		}
+		feed, err := decodeFeedPage(resp)
+		if page == 0 && err == nil {
+			next = FeedValidators{ETag: resp.Header.Get("ETag"), LastModified: resp.Header.Get("Last-Modified")}
+		}
+		resp.Body.Close()
+		if err != nil {
+			return nil, prev, err
+		}
+
+		reachedKnown := false
+		for _, entry := range feed.Entries {
+			infoHash := strings.TrimPrefix(entry.ID, "urn:btih:")
+			if known != nil && known(infoHash) {
+				reachedKnown = true
+				releases = append(releases, knownAtomEntry(entry))
+				continue
+			}
+			releases = append(releases, parseAtomEntry(entry))
+		}
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("fetch releases feed: status %d", resp.StatusCode)
+		pageURL = ""
+		if !reachedKnown {
+			pageURL = feedNextPage(feed, resp.Request.URL)
+			if pageURL == "" && known != nil {
+				fmt.Println("Warning: releases feed: no known release found and no older page; the feed may be truncated")
+			}
+		}
 	}
+	return releases, next, nil
+}
 
+// decodeFeedPage reads one page of the feed, turning a 304 into
+// ErrNotModified.
+func decodeFeedPage(resp *http.Response) (atomFeed, error) {
 	var feed atomFeed
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotModified:
+		return feed, ErrNotModified
+	default:
+		return feed, fmt.Errorf("fetch releases feed: status %d", resp.StatusCode)
+	}
 	if err := xml.NewDecoder(resp.Body).Decode(&feed); err != nil {
-		return nil, fmt.Errorf("decode releases feed: %w", err)
+		return feed, fmt.Errorf("decode releases feed: %w", err)
 	}
+	return feed, nil
+}
 
-	releases := make([]model.Release, 0, len(feed.Entries))
-	for _, entry := range feed.Entries {
-		releases = append(releases, parseAtomEntry(entry))
+// feedNextPage returns the absolute URL of the feed's next (older) page,
+// or "" when it has none.
+func feedNextPage(feed atomFeed, base *url.URL) string {
+	for _, link := range feed.Links {
+		if link.Rel != "next" || link.Href == "" {
+			continue
+		}
+		ref, err := url.Parse(link.Href)
+		if err != nil {
+			return ""
+		}
+		return base.ResolveReference(ref).String()
 	}
-
-	return releases, nil
+	return ""
 }
 
-func parseAtomEntry(entry atomEntry) model.Release {
+// knownAtomEntry reads only what FetchReleases needs of an already
+// archived release: its identity and category.
+func knownAtomEntry(entry atomEntry) model.Release {
 	release := model.Release{
-		Title:       strings.TrimSpace(entry.Title),
-		Variant:     entry.Category.Term,
-		PublishedAt: strings.TrimSpace(entry.Published),
-		InfoHash:    strings.TrimPrefix(entry.ID, "urn:btih:"),
+		Title:    strings.TrimSpace(entry.Title),
+		Variant:  entry.Category.Term,
+		InfoHash: strings.TrimPrefix(entry.ID, "urn:btih:"),
 	}
 	if release.Variant == "" {
 		release.Variant = "regular"
 	}
-	release.NormalizedVariant = parse.NormalizeVariant(release.Variant)
+	release.NormalizedVariant, release.Status = parse.ReleaseVariant(release.Variant, release.Title)
+	return release
+}
+
+func parseAtomEntry(entry atomEntry) model.Release {
+	release := knownAtomEntry(entry)
+	release.PublishedAt, _ = parse.Timestamp(entry.Published)
 
 	for _, link := range entry.Links {
 		switch {
 		case strings.HasPrefix(link.Href, "magnet:"):
 			release.MagnetURI = link.Href
-			release.CRC32 = crcFromMagnet(link.Href)
+			release.Magnet = parse.Magnet(link.Href)
+			if release.Magnet != nil && release.Magnet.File != nil {
+				release.CRC32 = release.Magnet.File.CRC32
+				release.Resolution = release.Magnet.File.Resolution
+			}
 		case link.Rel == "enclosure":
 			release.TorrentURL = link.Href
 		case link.Rel == "related":
@@ -115,9 +256,11 @@ func parseAtomEntry(entry atomEntry) model.Release {
 			case "manga chapters":
 				release.MangaChapters = val
 				release.MangaChapterRange = parse.ChapterRange(val)
+				release.MangaChapterRefs = parse.References(val, model.SegmentMangaChapter)
 			case "anime episodes":
 				release.AnimeEpisodes = val
 				release.AnimeEpisodeRange = parse.ChapterRange(val)
+				release.AnimeEpisodeRefs = parse.References(val, model.SegmentAnimeEpisode)
 			}
 		})
 
@@ -131,28 +274,8 @@ func parseAtomEntry(entry atomEntry) model.Release {
 				}
 			})
 		})
+		release.ChangelogEntries = parse.Changelog(release.Changelog)
 	}
 
 	return release
 }
-
-// crcFromMagnet extracts the CRC32 embedded in a magnet URI's "dn" filename.
-func crcFromMagnet(magnetURI string) string {
-	raw := strings.TrimPrefix(magnetURI, "magnet:?")
-	values, err := url.ParseQuery(raw)
-	if err != nil {
-		return ""
-	}
-
-	dn := values.Get("dn")
-	if dn == "" {
-		return ""
-	}
-
-	match := dnCRCRe.FindStringSubmatch(dn)
-	if len(match) < 2 {
-		return ""
-	}
-
-	return strings.ToUpper(match[1])
-}
@@ -0,0 +1,156 @@
+package fetch
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// atomPage builds a feed page with one entry per infohash, linking to next
+// when it's set.
+func atomPage(next string, infoHashes ...string) string {
+	var b strings.Builder
+	b.WriteString(`<?xml version="1.0" encoding="utf-8"?><feed xmlns="http://www.w3.org/2005/Atom">`)
+	if next != "" {
+		fmt.Fprintf(&b, `<link rel="next" href="%s"/>`, next)
+	}
+	for i, h := range infoHashes {
+		fmt.Fprintf(&b, `<entry><id>urn:btih:%s</id><title>Wano %02d</title><published>2025-01-0%dT12:00:00.000Z</published>`+
+			`<category term="regular"/><link rel="alternate" href="magnet:?xt=urn:btih:%s&amp;dn=%%5BOne%%20Pace%%5D%%5B1%%5D%%20Wano%%20%02d%%20%%5B1080p%%5D%%5BABCDEF0%d%%5D.mkv"/>`+
+			`<link rel="enclosure" href="https://nyaa.si/download/%d.torrent"/></entry>`,
+			h, i+1, i+1, h, i+1, i+1, i+1)
+	}
+	b.WriteString(`</feed>`)
+	return b.String()
+}
+
+func knownHashes(hashes ...string) func(string) bool {
+	return func(h string) bool {
+		for _, k := range hashes {
+			if h == k {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+func TestFetchReleasesNotModified(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") == `"v1"` && r.Header.Get("If-Modified-Since") == "Wed, 01 Jan 2025 12:00:00 GMT" {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v2"`)
+		w.Header().Set("Last-Modified", "Thu, 02 Jan 2025 12:00:00 GMT")
+		fmt.Fprint(w, atomPage("", "aaa"))
+	}))
+	defer srv.Close()
+
+	prev := FeedValidators{ETag: `"v1"`, LastModified: "Wed, 01 Jan 2025 12:00:00 GMT"}
+	releases, next, err := fetchReleasesFrom(srv.URL, prev, knownHashes("aaa"))
+	if !errors.Is(err, ErrNotModified) {
+		t.Fatalf("err = %v, want ErrNotModified", err)
+	}
+	if len(releases) != 0 || next != prev {
+		t.Errorf("got %d releases and validators %+v, want none and the previous ones", len(releases), next)
+	}
+
+	// A changed feed comes back with its new validators.
+	releases, next, err = fetchReleasesFrom(srv.URL, FeedValidators{ETag: `"v0"`}, knownHashes("aaa"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(releases) != 1 || next != (FeedValidators{ETag: `"v2"`, LastModified: "Thu, 02 Jan 2025 12:00:00 GMT"}) {
+		t.Errorf("got %d releases and validators %+v", len(releases), next)
+	}
+}
+
+func TestFetchReleasesStopsAtKnown(t *testing.T) {
+	var olderPage atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("page") == "2" {
+			olderPage.Add(1)
+			fmt.Fprint(w, atomPage("", "ccc"))
+			return
+		}
+		fmt.Fprint(w, atomPage("?page=2", "new", "aaa"))
+	}))
+	defer srv.Close()
+
+	releases, _, err := fetchReleasesFrom(srv.URL, FeedValidators{}, knownHashes("aaa"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if olderPage.Load() != 0 {
+		t.Error("followed the next page after reaching a known release")
+	}
+	if len(releases) != 2 {
+		t.Fatalf("got %d releases, want 2", len(releases))
+	}
+	fresh, known := releases[0], releases[1]
+	if fresh.InfoHash != "new" || fresh.CRC32 != "ABCDEF01" || fresh.MagnetURI == "" || fresh.TorrentURL == "" || !fresh.PublishedAt.Known() {
+		t.Errorf("new release not parsed in full: %+v", fresh)
+	}
+	if known.InfoHash != "aaa" || known.Title != "Wano 02" || known.Variant != "regular" {
+		t.Errorf("known release = %+v, want its identity and category", known)
+	}
+	if known.MagnetURI != "" || known.TorrentURL != "" || known.CRC32 != "" || known.PublishedAt.Known() {
+		t.Errorf("known release parsed in full: %+v", known)
+	}
+}
+
+func TestFetchReleasesFollowsNextPage(t *testing.T) {
+	var conditionalOlder atomic.Bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("page") == "2" {
+			if r.Header.Get("If-None-Match") != "" {
+				conditionalOlder.Store(true)
+			}
+			fmt.Fprint(w, atomPage("?page=3", "older", "aaa"))
+			return
+		}
+		w.Header().Set("ETag", `"v2"`)
+		fmt.Fprint(w, atomPage("?page=2", "newest"))
+	}))
+	defer srv.Close()
+
+	releases, next, err := fetchReleasesFrom(srv.URL, FeedValidators{ETag: `"v1"`}, knownHashes("aaa"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var hashes []string
+	for _, r := range releases {
+		hashes = append(hashes, r.InfoHash)
+	}
+	if got := strings.Join(hashes, ","); got != "newest,older,aaa" {
+		t.Errorf("releases = %s, want newest,older,aaa", got)
+	}
+	if conditionalOlder.Load() {
+		t.Error("sent validators with an older page")
+	}
+	if next.ETag != `"v2"` {
+		t.Errorf("validators = %+v, want the first page's", next)
+	}
+}
+
+func TestFetchReleasesPageLimit(t *testing.T) {
+	var pages atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := pages.Add(1)
+		fmt.Fprint(w, atomPage(fmt.Sprintf("?page=%d", n+1), fmt.Sprintf("hash%d", n)))
+	}))
+	defer srv.Close()
+
+	releases, _, err := fetchReleasesFrom(srv.URL, FeedValidators{}, knownHashes("never"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pages.Load() != maxFeedPages || len(releases) != maxFeedPages {
+		t.Errorf("read %d pages (%d releases), want %d", pages.Load(), len(releases), maxFeedPages)
+	}
+}
@@ -0,0 +1,61 @@
+package fetch
+
+import (
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+
+	"metadata-service/internal/config"
+	"metadata-service/internal/model"
+	"metadata-service/internal/parse"
+)
+
+// sagaMappingEntry is one saga of config.SagaMapping.
+type sagaMappingEntry struct {
+	Title string   `yaml:"title"`
+	Arcs  []string `yaml:"arcs"`
+}
+
+// assignSagas sets each arc's Saga/SagaID: from the descriptions sheet's
+// saga column (sheetSagas, by arc title) when it has one, else the arc
+// list's saga column (already in Arc.Saga), else config.SagaMapping. Arc
+// titles are compared by their Canonical keys (see parse.TitleAliases),
+// with config.ArcTitleAliases. Arcs in none of these are left without a
+// saga and reported.
+func assignSagas(arcs []model.Arc, sheetSagas map[string]string) error {
+	var mapping []sagaMappingEntry
+	if err := yaml.Unmarshal(config.SagaMapping, &mapping); err != nil {
+		return fmt.Errorf("parse saga mapping: %w", err)
+	}
+
+	canonical := parse.NewTitleAliases(config.ArcTitleAliases).Canonical
+
+	mapped := make(map[string]string)
+	for _, saga := range mapping {
+		for _, arc := range saga.Arcs {
+			mapped[canonical(arc)] = saga.Title
+		}
+	}
+	fromSheet := make(map[string]string, len(sheetSagas))
+	for arc, saga := range sheetSagas {
+		fromSheet[canonical(arc)] = saga
+	}
+
+	for i := range arcs {
+		key := canonical(arcs[i].Title)
+		saga, ok := fromSheet[key]
+		if !ok && arcs[i].Saga != "" {
+			saga, ok = arcs[i].Saga, true
+		}
+		if !ok {
+			saga, ok = mapped[key]
+		}
+		if !ok {
+			fmt.Printf("Warning: arc %q has no saga; add it to config/sagas.yml\n", arcs[i].Title)
+			continue
+		}
+		arcs[i].Saga = saga
+		arcs[i].SagaID = slugify(saga)
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+// Package index builds the reverse lookups from a manga chapter or original
+// anime episode number to the One Pace episodes that adapt it
+// (data/index/*.json), and answers "where" queries against them.
+package index
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+
+	"metadata-service/internal/model"
+)
+
+const (
+	ChaptersFile      = "chapters.json"
+	AnimeEpisodesFile = "anime-episodes.json"
+)
+
+// Index maps a chapter/anime episode number to the EpisodeIDs covering it,
+// in arc/episode order.
+type Index map[int][]string
+
+// Build indexes every numbered segment of each episode's ChapterRefs and
+// AnimeEpisodeRefs. Segments are routed by their own kind, so an explicit
+// "Ch." inside an anime-episode cell still lands in the chapter index.
+// Specials aren't numbered in the source series, so they're skipped.
+func Build(arcs []model.Arc) (chapters, animeEpisodes Index) {
+	chapters, animeEpisodes = Index{}, Index{}
+	for _, arc := range arcs {
+		for _, ep := range arc.Episodes {
+			if ep.ID == "" {
+				continue
+			}
+			for _, refs := range []*model.References{ep.ChapterRefs, ep.AnimeEpisodeRefs} {
+				if refs == nil {
+					continue
+				}
+				for _, seg := range refs.Segments {
+					var target Index
+					switch seg.Kind {
+					case model.SegmentMangaChapter:
+						target = chapters
+					case model.SegmentAnimeEpisode:
+						target = animeEpisodes
+					default:
+						continue
+					}
+					for n := seg.Start; n <= seg.End; n++ {
+						target.add(n, ep.ID)
+					}
+				}
+			}
+		}
+	}
+	return chapters, animeEpisodes
+}
+
+func (ix Index) add(n int, episodeID string) {
+	for _, id := range ix[n] {
+		if id == episodeID {
+			return
+		}
+	}
+	ix[n] = append(ix[n], episodeID)
+}
+
+// Load reads an index file written by the exporter.
+func Load(path string) (Index, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	ix := Index{}
+	if err := json.Unmarshal(raw, &ix); err != nil {
+		return nil, fmt.Errorf("decode %s: %w", path, err)
+	}
+	return ix, nil
+}
+
+// Lookup returns the EpisodeIDs covering n. When nothing adapts n (a
+// skipped chapter, or one past the latest release), it falls back to the
+// next number that is covered and reports it as next, so "I read up to
+// chapter N" still gets a starting point. next is 0 when n was found, or
+// when nothing after it is covered either.
+func (ix Index) Lookup(n int) (episodeIDs []string, next int) {
+	if ids, ok := ix[n]; ok {
+		return ids, 0
+	}
+	keys := make([]int, 0, len(ix))
+	for k := range ix {
+		keys = append(keys, k)
+	}
+	sort.Ints(keys)
+	i := sort.SearchInts(keys, n)
+	if i == len(keys) {
+		return nil, 0
+	}
+	return ix[keys[i]], keys[i]
+}
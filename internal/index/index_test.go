@@ -0,0 +1,49 @@
+package index
+
+import (
+	"reflect"
+	"testing"
+
+	"metadata-service/internal/model"
+	"metadata-service/internal/parse"
+)
+
+func episode(id, chapters, animeEps string) model.Episode {
+	return model.Episode{
+		ID:               id,
+		ChapterRefs:      parse.References(chapters, model.SegmentMangaChapter),
+		AnimeEpisodeRefs: parse.References(animeEps, model.SegmentAnimeEpisode),
+	}
+}
+
+func TestBuildAndLookup(t *testing.T) {
+	arcs := []model.Arc{
+		{Episodes: []model.Episode{
+			episode("rd-001", "Ch. 1", "Episode of East Blue, Ep. 312 (Intro)"),
+			episode("rd-002", "Ch. 2-3", "Ep. 1-2"),
+		}},
+		{Episodes: []model.Episode{
+			episode("ot-001", "Ch. 3, 8-9", "Ep. 2, 4"),
+		}},
+	}
+
+	chapters, anime := Build(arcs)
+
+	if got := chapters[3]; !reflect.DeepEqual(got, []string{"rd-002", "ot-001"}) {
+		t.Errorf("chapter 3 = %v, want both episodes in order", got)
+	}
+	if got := anime[312]; !reflect.DeepEqual(got, []string{"rd-001"}) {
+		t.Errorf("anime ep 312 = %v, want [rd-001]", got)
+	}
+
+	if ids, next := chapters.Lookup(8); next != 0 || !reflect.DeepEqual(ids, []string{"ot-001"}) {
+		t.Errorf("Lookup(8) = %v, %d", ids, next)
+	}
+	// Chapters 4-7 are skipped: fall forward to chapter 8.
+	if ids, next := chapters.Lookup(5); next != 8 || !reflect.DeepEqual(ids, []string{"ot-001"}) {
+		t.Errorf("Lookup(5) = %v, %d, want [ot-001], 8", ids, next)
+	}
+	if ids, next := chapters.Lookup(100); ids != nil || next != 0 {
+		t.Errorf("Lookup(100) = %v, %d, want nothing", ids, next)
+	}
+}
@@ -0,0 +1,138 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// DateLayout is how a Date is written out: ISO 8601, "2025-05-03".
+	DateLayout = "2006-01-02"
+
+	// TimestampLayout is how a Timestamp is written out: RFC 3339 in UTC
+	// with milliseconds, matching the releases feed.
+	TimestampLayout = "2006-01-02T15:04:05.000Z07:00"
+)
+
+// Date is a calendar date from the sheet, e.g. an episode's release date.
+// Time is the zero time when the date is unknown; Raw then keeps the
+// source text ("To Be Released", or something unparseable) so it survives
+// a round trip. It marshals as a plain string: DateLayout when known,
+// Raw otherwise. See parse.Date for reading the sheet's formats.
+type Date struct {
+	Time time.Time
+	Raw  string
+}
+
+// NewDate returns the known Date y-m-d.
+func NewDate(y int, m time.Month, d int) Date {
+	return Date{Time: time.Date(y, m, d, 0, 0, 0, 0, time.UTC)}
+}
+
+// Known reports whether d holds an actual date.
+func (d Date) Known() bool { return !d.Time.IsZero() }
+
+// After reports whether d is later than other. A known date is later than
+// an unknown one, and an unknown date is never later than anything, so
+// bad values can't win a "newest" comparison.
+func (d Date) After(other Date) bool {
+	if !d.Known() {
+		return false
+	}
+	return !other.Known() || d.Time.After(other.Time)
+}
+
+func (d Date) String() string {
+	if d.Known() {
+		return d.Time.Format(DateLayout)
+	}
+	return d.Raw
+}
+
+func (d Date) MarshalJSON() ([]byte, error) { return json.Marshal(d.String()) }
+
+func (d *Date) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	*d = dateFromString(s)
+	return nil
+}
+
+func (d Date) MarshalYAML() (any, error) { return d.String(), nil }
+
+func (d *Date) UnmarshalYAML(n *yaml.Node) error {
+	var s string
+	if err := n.Decode(&s); err != nil {
+		return err
+	}
+	*d = dateFromString(s)
+	return nil
+}
+
+// dateFromString reads a stored Date. Only the written-out layout (with or
+// without zero padding) is recognized; anything else is kept as Raw.
+func dateFromString(s string) Date {
+	if t, err := time.Parse("2006-1-2", s); err == nil {
+		return Date{Time: t}
+	}
+	return Date{Raw: s}
+}
+
+// Timestamp is a point in time, e.g. a release's publish time. Like Date,
+// Time is zero when unknown and Raw keeps the source text.
+type Timestamp struct {
+	Time time.Time
+	Raw  string
+}
+
+// Known reports whether t holds an actual time.
+func (t Timestamp) Known() bool { return !t.Time.IsZero() }
+
+// After reports whether t is later than other, with unknown times sorting
+// before every known one (see Date.After).
+func (t Timestamp) After(other Timestamp) bool {
+	if !t.Known() {
+		return false
+	}
+	return !other.Known() || t.Time.After(other.Time)
+}
+
+func (t Timestamp) String() string {
+	if t.Known() {
+		return t.Time.UTC().Format(TimestampLayout)
+	}
+	return t.Raw
+}
+
+func (t Timestamp) MarshalJSON() ([]byte, error) { return json.Marshal(t.String()) }
+
+func (t *Timestamp) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	*t = timestampFromString(s)
+	return nil
+}
+
+func (t Timestamp) MarshalYAML() (any, error) { return t.String(), nil }
+
+func (t *Timestamp) UnmarshalYAML(n *yaml.Node) error {
+	var s string
+	if err := n.Decode(&s); err != nil {
+		return err
+	}
+	*t = timestampFromString(s)
+	return nil
+}
+
+func timestampFromString(s string) Timestamp {
+	if ts, err := time.Parse(time.RFC3339, s); err == nil {
+		return Timestamp{Time: ts}
+	}
+	return Timestamp{Raw: s}
+}
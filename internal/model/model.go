@@ -11,20 +11,43 @@ type Arc struct {
 	// slugified Title for arcs with no sheet yet). Unlike Arc, it is never
 	// reassigned by normalizeArcIDs, so it's safe to use as a join key
 	// across scrapes.
-	ID  string `json:"id,omitempty" yaml:"id,omitempty"`
-	Arc int    `json:"arc" yaml:"arc"`
+	ID string `json:"id,omitempty" yaml:"id,omitempty"`
+	// Arc is the arc's position in the sheet, renumbered 1, 2, 3, ... by
+	// normalizeArcIDs so it can serve as a season number.
+	Arc int `json:"arc" yaml:"arc"`
+	// Number is the sheet's own arc number as displayed, e.g. "6.5" for an
+	// arc slotted in between two others, and SortKey its numeric value.
+	Number  string  `json:"number,omitempty" yaml:"number,omitempty"`
+	SortKey float64 `json:"sort_key,omitempty" yaml:"sort_key,omitempty"`
 
 	Title string `json:"title" yaml:"title"`
 
+	// SagaID/Saga name the saga (East Blue, Alabasta, ...) the arc belongs
+	// to. See Saga.
+	SagaID string `json:"saga_id,omitempty" yaml:"saga_id,omitempty"`
+	Saga   string `json:"saga,omitempty" yaml:"saga,omitempty"`
+
 	AudioLanguages    string `json:"audio_languages" yaml:"audio_languages"`
 	SubtitleLanguages string `json:"subtitle_languages" yaml:"subtitle_languages"`
 	Resolution        string `json:"resolution" yaml:"resolution"`
+	// ResolutionList is Resolution split into normalized values, e.g.
+	// "720p,1080p" -> ["720p", "1080p"]. See internal/parse.Resolutions.
+	ResolutionList []string `json:"resolution_list,omitempty" yaml:"resolution_list,omitempty"`
+
+	// Structured forms of AudioLanguages/SubtitleLanguages (see
+	// internal/parse.Languages). LanguageWarnings lists any token in either
+	// string that couldn't be recognised.
+	AudioLanguageList    []Language `json:"audio_language_list,omitempty" yaml:"audio_language_list,omitempty"`
+	SubtitleLanguageList []Language `json:"subtitle_language_list,omitempty" yaml:"subtitle_language_list,omitempty"`
+	LanguageWarnings     []string   `json:"language_warnings,omitempty" yaml:"language_warnings,omitempty"`
 
 	MangaChapters         string        `json:"manga_chapters" yaml:"manga_chapters"`
 	MangaChapterRange     *ChapterRange `json:"manga_chapter_range,omitempty" yaml:"manga_chapter_range,omitempty"`
+	MangaChapterRefs      *References   `json:"manga_chapter_refs,omitempty" yaml:"manga_chapter_refs,omitempty"`
 	NumberOfChapters      string        `json:"number_of_chapters" yaml:"number_of_chapters"`
 	AnimeEpisodes         string        `json:"anime_episodes" yaml:"anime_episodes"`
 	AnimeEpisodeRange     *ChapterRange `json:"anime_episode_range,omitempty" yaml:"anime_episode_range,omitempty"`
+	AnimeEpisodeRefs      *References   `json:"anime_episode_refs,omitempty" yaml:"anime_episode_refs,omitempty"`
 	EpisodesAdapted       string        `json:"episodes_adapted" yaml:"episodes_adapted"`
 	FillerEpisodes        string        `json:"filler_episodes" yaml:"filler_episodes"`
 	TimeSavedMins         string        `json:"time_saved_mins" yaml:"time_saved_mins"`
@@ -32,22 +55,88 @@ type Arc struct {
 	TimeSavedPercent      string        `json:"time_saved_percent" yaml:"time_saved_percent"`
 	TimeSavedPercentValue *float64      `json:"time_saved_percent_value,omitempty" yaml:"time_saved_percent_value,omitempty"`
 
+	// The episode counts and total runtimes the time saved figures are
+	// computed from, as in the sheet plus parsed (runtimes in seconds; see
+	// internal/parse.Runtime).
+	OnePaceEpisodes       string `json:"one_pace_episodes,omitempty" yaml:"one_pace_episodes,omitempty"`
+	OnePaceEpisodesValue  *int   `json:"one_pace_episodes_value,omitempty" yaml:"one_pace_episodes_value,omitempty"`
+	AnimeRuntime          string `json:"anime_runtime,omitempty" yaml:"anime_runtime,omitempty"`
+	AnimeRuntimeSeconds   *int   `json:"anime_runtime_seconds,omitempty" yaml:"anime_runtime_seconds,omitempty"`
+	OnePaceRuntime        string `json:"one_pace_runtime,omitempty" yaml:"one_pace_runtime,omitempty"`
+	OnePaceRuntimeSeconds *int   `json:"one_pace_runtime_seconds,omitempty" yaml:"one_pace_runtime_seconds,omitempty"`
+
 	Status string `json:"status" yaml:"status"` // WIP / TBR / ""
 
 	Episodes []Episode `json:"episodes" yaml:"episodes"`
 
 	GID string `json:"gid,omitempty" yaml:"gid,omitempty"`
+
+	// Extra holds the arc list's other columns, by header as shown in the
+	// sheet, so new columns reach consumers before they get a field of
+	// their own. Empty cells are left out.
+	Extra map[string]string `json:"extra,omitempty" yaml:"extra,omitempty"`
+}
+
+// Saga groups consecutive arcs (data/sagas.{json,yml}). Number orders
+// sagas by their first arc.
+type Saga struct {
+	ID     string   `json:"id" yaml:"id"`
+	Number int      `json:"number" yaml:"number"`
+	Title  string   `json:"title" yaml:"title"`
+	ArcIDs []string `json:"arc_ids" yaml:"arc_ids"`
+}
+
+// Language is one entry of an arc's audio or subtitle language list, e.g.
+// "PT-BR" or "EN(CC)" or "ES(1-5,8-9)".
+type Language struct {
+	Tag string `json:"tag" yaml:"tag"` // BCP 47, e.g. "en", "pt-BR"
+
+	ClosedCaptions bool `json:"closed_captions,omitempty" yaml:"closed_captions,omitempty"`
+
+	// Episodes is the sheet's episode annotation, verbatim, when the
+	// language only covers part of the arc ("1-5,8-9", "1-4, 31-"). Empty
+	// means the whole arc.
+	Episodes string `json:"episodes,omitempty" yaml:"episodes,omitempty"`
 }
 
 // ChapterRange is a best-effort parse of a "start-end" range string. It's
 // only populated when the source text is an unambiguous two-number range —
 // left nil for non-contiguous ("1 - 4, 19") or prose-style values rather
-// than guessing. See internal/parse.ChapterRange.
+// than guessing. See internal/parse.ChapterRange, and References for the
+// full form.
 type ChapterRange struct {
 	Start int `json:"start" yaml:"start"`
 	End   int `json:"end" yaml:"end"`
 }
 
+// References is the full parse of a chapter/anime-episode reference string
+// such as "1 - 4, 19" or "Episode of East Blue, Ep. 312 (Intro)": every
+// segment it could make sense of, plus the pieces it couldn't. See
+// internal/parse.References.
+type References struct {
+	Segments []Segment `json:"segments,omitempty" yaml:"segments,omitempty"`
+	Unparsed []string  `json:"unparsed,omitempty" yaml:"unparsed,omitempty"`
+}
+
+// Segment kinds.
+const (
+	SegmentMangaChapter = "manga_chapter"
+	SegmentAnimeEpisode = "anime_episode"
+	SegmentSpecial      = "special"
+)
+
+// Segment is one piece of a References list: a single number (Start ==
+// End), an inclusive range, or a named special (Name set, with numbers
+// only when the special itself is numbered, e.g. "Straw Hat Theater 1-5").
+// Part carries an annotation such as "Intro" from "Ep. 312 (Intro)".
+type Segment struct {
+	Kind  string `json:"kind" yaml:"kind"`
+	Start int    `json:"start,omitempty" yaml:"start,omitempty"`
+	End   int    `json:"end,omitempty" yaml:"end,omitempty"`
+	Name  string `json:"name,omitempty" yaml:"name,omitempty"`
+	Part  string `json:"part,omitempty" yaml:"part,omitempty"`
+}
+
 //
 // ===============================
 //       EPISODE STRUCTS
@@ -64,12 +153,19 @@ type Episode struct {
 
 	Title       string `json:"title" yaml:"title"`
 	Description string `json:"description" yaml:"description"`
+	// Titles/Descriptions hold the title and description in every
+	// language the descriptions sheet has, English included. Title and
+	// Description are the English ones.
+	Titles       Localized `json:"titles,omitempty" yaml:"titles,omitempty"`
+	Descriptions Localized `json:"descriptions,omitempty" yaml:"descriptions,omitempty"`
 
-	Chapters     string        `json:"chapters" yaml:"chapters"`
-	ChapterRange *ChapterRange `json:"chapter_range,omitempty" yaml:"chapter_range,omitempty"`
-	AnimeEps     string        `json:"episodes" yaml:"episodes"`
+	Chapters         string        `json:"chapters" yaml:"chapters"`
+	ChapterRange     *ChapterRange `json:"chapter_range,omitempty" yaml:"chapter_range,omitempty"`
+	ChapterRefs      *References   `json:"chapter_refs,omitempty" yaml:"chapter_refs,omitempty"`
+	AnimeEps         string        `json:"episodes" yaml:"episodes"`
+	AnimeEpisodeRefs *References   `json:"anime_episode_refs,omitempty" yaml:"anime_episode_refs,omitempty"`
 
-	Released string `json:"released" yaml:"released"`
+	Released Date `json:"released" yaml:"released"`
 
 	HasExtended bool                `json:"has_extended" yaml:"has_extended"`
 	Files       EpisodeFileVariants `json:"files" yaml:"files"`
@@ -84,12 +180,18 @@ type Episode struct {
 type EpisodeFileVariants struct {
 	Normal   *EpisodeFile `json:"normal,omitempty" yaml:"normal,omitempty"`
 	Extended *EpisodeFile `json:"extended,omitempty" yaml:"extended,omitempty"`
+
+	// Alternates holds encodes of the same episode at other resolutions
+	// than Normal/Extended (e.g. a 720p alongside a 1080p Normal). Each
+	// file's Version and Resolution say which slot it's an alternate for.
+	Alternates []EpisodeFile `json:"alternates,omitempty" yaml:"alternates,omitempty"`
 }
 
 type EpisodeFile struct {
 	Version string `json:"version" yaml:"version"` // "normal" | "extended" | etc
 
 	CRC32         string `json:"crc32" yaml:"crc32"`
+	Resolution    string `json:"resolution,omitempty" yaml:"resolution,omitempty"` // "1080p" | "720p" | "480p" | "" if unknown
 	Length        string `json:"length,omitempty" yaml:"length,omitempty"`
 	LengthSeconds int    `json:"length_seconds,omitempty" yaml:"length_seconds,omitempty"`
 
@@ -100,6 +202,38 @@ type EpisodeFile struct {
 	// ReleaseInfoHash links back to the Release (by InfoHash) that supplied
 	// this file's magnet/torrent links, when known.
 	ReleaseInfoHash string `json:"release_info_hash,omitempty" yaml:"release_info_hash,omitempty"`
+
+	// Magnet is MagnetURI parsed into its parts.
+	Magnet *Magnet `json:"magnet,omitempty" yaml:"magnet,omitempty"`
+}
+
+// Magnet is a magnet URI broken into its parameters, so consumers get the
+// file's name and size without parsing magnet strings. See parse.Magnet.
+type Magnet struct {
+	InfoHash    string `json:"info_hash,omitempty" yaml:"info_hash,omitempty"`       // v1 "btih", lowercase hex
+	InfoHashV2  string `json:"info_hash_v2,omitempty" yaml:"info_hash_v2,omitempty"` // v2 "btmh" multihash, lowercase hex
+	DisplayName string `json:"display_name,omitempty" yaml:"display_name,omitempty"`
+	ExactLength int64  `json:"exact_length,omitempty" yaml:"exact_length,omitempty"` // bytes, from "xl"
+
+	// Trackers is left empty in the stored archives, whose magnets are
+	// compact; see data/trackers.json and config.MagnetTrackers.
+	Trackers []string `json:"trackers,omitempty" yaml:"trackers,omitempty"`
+
+	// File is what the release filename in DisplayName says about the file.
+	File *ReleaseFilename `json:"file,omitempty" yaml:"file,omitempty"`
+}
+
+// ReleaseFilename holds the fields encoded in a One Pace release filename,
+// e.g. "[One Pace][129-132] Drum Island 01 [1080p][FD2B4F32].mkv".
+type ReleaseFilename struct {
+	Chapters     string        `json:"chapters,omitempty" yaml:"chapters,omitempty"` // "129-132", verbatim
+	ChapterRange *ChapterRange `json:"chapter_range,omitempty" yaml:"chapter_range,omitempty"`
+	Title        string        `json:"title,omitempty" yaml:"title,omitempty"` // "Drum Island 01"
+	Arc          string        `json:"arc,omitempty" yaml:"arc,omitempty"`     // "Drum Island"
+	Episode      *int          `json:"episode,omitempty" yaml:"episode,omitempty"`
+	Resolution   string        `json:"resolution,omitempty" yaml:"resolution,omitempty"`
+	CRC32        string        `json:"crc32,omitempty" yaml:"crc32,omitempty"`
+	Extension    string        `json:"extension,omitempty" yaml:"extension,omitempty"` // "mkv"
 }
 
 // CurrentEpisode is the "current" view of a single episode derived from the
@@ -117,7 +251,15 @@ type CurrentEpisode struct {
 	Description string `json:"description" yaml:"description"`
 	Chapters    string `json:"chapters" yaml:"chapters"`
 	AnimeEps    string `json:"episodes" yaml:"episodes"`
-	Released    string `json:"released" yaml:"released"`
+	Released    Date   `json:"released" yaml:"released"`
+
+	// Chapters/AnimeEps parsed; see Episode.ChapterRefs.
+	ChapterRefs      *References `json:"chapter_refs,omitempty" yaml:"chapter_refs,omitempty"`
+	AnimeEpisodeRefs *References `json:"anime_episode_refs,omitempty" yaml:"anime_episode_refs,omitempty"`
+
+	// Localized titles/descriptions; see Episode.Titles.
+	Titles       Localized `json:"titles,omitempty" yaml:"titles,omitempty"`
+	Descriptions Localized `json:"descriptions,omitempty" yaml:"descriptions,omitempty"`
 
 	Files EpisodeFileVariants `json:"files" yaml:"files"`
 }
@@ -125,8 +267,18 @@ type CurrentEpisode struct {
 type EpisodeMeta struct {
 	Title       string `json:"title" yaml:"title"`
 	Description string `json:"description" yaml:"description"`
+
+	Titles       Localized `json:"titles,omitempty" yaml:"titles,omitempty"`
+	Descriptions Localized `json:"descriptions,omitempty" yaml:"descriptions,omitempty"`
 }
 
+// Localized is a text in several languages, keyed by BCP 47 tag ("en",
+// "es", "pt-BR").
+type Localized map[string]string
+
+// DefaultLanguage is the language of the plain Title/Description fields.
+const DefaultLanguage = "en"
+
 type EpisodeArchiveEntry struct {
 	// ArcID/EpisodeID are the stable identifiers (see Arc.ID/Episode.ID)
 	// this archive entry belongs to, so it can be joined back reliably
@@ -140,13 +292,27 @@ type EpisodeArchiveEntry struct {
 	Description string `json:"description" yaml:"description"`
 	Chapters    string `json:"chapters" yaml:"chapters"`
 	AnimeEps    string `json:"episodes" yaml:"episodes"`
-	Released    string `json:"released" yaml:"released"`
+	Released    Date   `json:"released" yaml:"released"`
+
+	// Chapters/AnimeEps parsed; see Episode.ChapterRefs.
+	ChapterRefs      *References `json:"chapter_refs,omitempty" yaml:"chapter_refs,omitempty"`
+	AnimeEpisodeRefs *References `json:"anime_episode_refs,omitempty" yaml:"anime_episode_refs,omitempty"`
+
+	// Localized titles/descriptions; see Episode.Titles.
+	Titles       Localized `json:"titles,omitempty" yaml:"titles,omitempty"`
+	Descriptions Localized `json:"descriptions,omitempty" yaml:"descriptions,omitempty"`
 
 	// Only the single file variant for this CRC
 	File EpisodeFile `json:"file" yaml:"file"`
 
-	// IsCurrent is true when no other archive entry sharing this entry's
-	// (ArcID, EpisodeID, File.Version) has a newer Released date.
+	// IsCurrent marks the files to download for this episode and variant.
+	// Among the entries sharing (EpisodeID, File.Version), one is picked:
+	// a file the releases feed marks outdated loses to one it doesn't,
+	// then the newest Released date wins, and a tie goes to the CRC32 that
+	// sorts first. The newest file at each other resolution is current
+	// too, as an alternate, if it isn't outdated and isn't older than the
+	// pick. An entry without a recorded resolution counts as its arc's
+	// highest.
 	IsCurrent bool `json:"is_current" yaml:"is_current"`
 }
 
@@ -156,24 +322,422 @@ type EpisodeArchiveEntry struct {
 // ===============================
 //
 
+// Release lifecycle statuses (Release.Status).
+const (
+	ReleaseActive    = "active"    // the current file for its cut
+	ReleaseOutdated  = "outdated"  // superseded by a newer release
+	ReleaseAlternate = "alternate" // an alternative cut, current alongside the main one
+)
+
 type Release struct {
-	Title         string   `json:"title" yaml:"title"`
-	Variant       string   `json:"variant" yaml:"variant"` // "regular" | "extended"
-	CRC32         string   `json:"crc32,omitempty" yaml:"crc32,omitempty"`
-	PublishedAt   string   `json:"published_at" yaml:"published_at"` // RFC3339
-	MangaChapters string   `json:"manga_chapters,omitempty" yaml:"manga_chapters,omitempty"`
-	AnimeEpisodes string   `json:"anime_episodes,omitempty" yaml:"anime_episodes,omitempty"`
-	Changelog     []string `json:"changelog,omitempty" yaml:"changelog,omitempty"`
-
-	InfoHash   string `json:"info_hash" yaml:"info_hash"`
-	NyaaURL    string `json:"nyaa_url,omitempty" yaml:"nyaa_url,omitempty"`
-	TorrentURL string `json:"torrent_url,omitempty" yaml:"torrent_url,omitempty"`
-	MagnetURI  string `json:"magnet_uri,omitempty" yaml:"magnet_uri,omitempty"`
+	Title         string    `json:"title" yaml:"title"`
+	Variant       string    `json:"variant" yaml:"variant"` // feed category: "regular" | "extended" | "alternate" | "outdated"
+	CRC32         string    `json:"crc32,omitempty" yaml:"crc32,omitempty"`
+	Resolution    string    `json:"resolution,omitempty" yaml:"resolution,omitempty"`
+	PublishedAt   Timestamp `json:"published_at" yaml:"published_at"`
+	MangaChapters string    `json:"manga_chapters,omitempty" yaml:"manga_chapters,omitempty"`
+	AnimeEpisodes string    `json:"anime_episodes,omitempty" yaml:"anime_episodes,omitempty"`
+	Changelog     []string  `json:"changelog,omitempty" yaml:"changelog,omitempty"`
+
+	InfoHash   string  `json:"info_hash" yaml:"info_hash"`
+	NyaaURL    string  `json:"nyaa_url,omitempty" yaml:"nyaa_url,omitempty"`
+	TorrentURL string  `json:"torrent_url,omitempty" yaml:"torrent_url,omitempty"`
+	MagnetURI  string  `json:"magnet_uri,omitempty" yaml:"magnet_uri,omitempty"`
+	Magnet     *Magnet `json:"magnet,omitempty" yaml:"magnet,omitempty"`
 
 	// NormalizedVariant re-expresses Variant in EpisodeFile.Version's
-	// vocabulary ("normal"/"extended") so the two can be joined/compared
-	// directly. See internal/parse.NormalizeVariant.
+	// vocabulary ("normal"/"extended"/"alternate") so the two can be
+	// joined/compared directly. For outdated releases it's the cut they
+	// were, read from the title. See internal/parse.ReleaseVariant.
 	NormalizedVariant string        `json:"normalized_variant,omitempty" yaml:"normalized_variant,omitempty"`
 	MangaChapterRange *ChapterRange `json:"manga_chapter_range,omitempty" yaml:"manga_chapter_range,omitempty"`
 	AnimeEpisodeRange *ChapterRange `json:"anime_episode_range,omitempty" yaml:"anime_episode_range,omitempty"`
+	MangaChapterRefs  *References   `json:"manga_chapter_refs,omitempty" yaml:"manga_chapter_refs,omitempty"`
+	AnimeEpisodeRefs  *References   `json:"anime_episode_refs,omitempty" yaml:"anime_episode_refs,omitempty"`
+
+	// ArcID/EpisodeID join the release to the episode guide — by CRC32
+	// through the episode archive when possible, otherwise by parsing
+	// Title. A whole-arc batch gets an ArcID but no EpisodeID. See
+	// data/reports/release-matches.json for releases that stay unmatched.
+	ArcID     string `json:"arc_id,omitempty" yaml:"arc_id,omitempty"`
+	EpisodeID string `json:"episode_id,omitempty" yaml:"episode_id,omitempty"`
+	// MatchedBy is "crc32", "title" or "filename" (the magnet's display
+	// name), whichever produced the IDs.
+	MatchedBy string `json:"matched_by,omitempty" yaml:"matched_by,omitempty"`
+
+	// ChangelogEntries is Changelog with each line classified. See
+	// parse.ChangelogLine.
+	ChangelogEntries []ChangelogEntry `json:"changelog_entries,omitempty" yaml:"changelog_entries,omitempty"`
+
+	// Status is the release's lifecycle: ReleaseActive, ReleaseOutdated
+	// or ReleaseAlternate.
+	Status string `json:"status,omitempty" yaml:"status,omitempty"`
+	// SupersededBy is the InfoHash of the release that replaced this one,
+	// for outdated releases whose successor is in the archive.
+	SupersededBy string `json:"superseded_by,omitempty" yaml:"superseded_by,omitempty"`
+}
+
+// NyaaTorrent is a torrent found by searching Nyaa's RSS feed for a CRC32,
+// for files the releases feed doesn't list. Seeders, Leechers and
+// Downloads are as of the lookup. See fetch.NyaaCache.
+type NyaaTorrent struct {
+	CRC32       string    `json:"crc32,omitempty" yaml:"crc32,omitempty"` // from the title
+	Title       string    `json:"title" yaml:"title"`
+	ViewURL     string    `json:"view_url" yaml:"view_url"`
+	TorrentURL  string    `json:"torrent_url,omitempty" yaml:"torrent_url,omitempty"`
+	MagnetURI   string    `json:"magnet_uri,omitempty" yaml:"magnet_uri,omitempty"` // compact: infohash + title
+	InfoHash    string    `json:"info_hash,omitempty" yaml:"info_hash,omitempty"`
+	Size        string    `json:"size,omitempty" yaml:"size,omitempty"` // as listed, e.g. "1.2 GiB"
+	SizeBytes   *int64    `json:"size_bytes,omitempty" yaml:"size_bytes,omitempty"`
+	PublishedAt Timestamp `json:"published_at" yaml:"published_at"`
+	Seeders     int       `json:"seeders" yaml:"seeders"`
+	Leechers    int       `json:"leechers" yaml:"leechers"`
+	Downloads   int       `json:"downloads" yaml:"downloads"`
+}
+
+// Where "backfill-links" found an archive entry's missing links
+// (LinkBackfillEntry.Sources).
+const (
+	LinkSourceRelease         = "release"          // releases archive, by CRC32
+	LinkSourceReleaseInfoHash = "release_infohash" // releases archive, by the entry's infohash
+	LinkSourceNyaaLink        = "nyaa_link"        // the other half of a Nyaa view/.torrent link pair
+	LinkSourceNyaa            = "nyaa"             // Nyaa search by CRC32
+	LinkSourceNyaaInfoHash    = "nyaa_infohash"    // Nyaa search by infohash
+	LinkSourceInfoHash        = "infohash"         // a magnet built from the infohash alone
+)
+
+// LinkBackfillReport is what "backfill-links" did (data/reports/links.json):
+// each episode archive entry that lacked a view, magnet or .torrent link,
+// what was filled in and from where, and what's still missing.
+type LinkBackfillReport struct {
+	Incomplete int                 `json:"incomplete" yaml:"incomplete"` // entries missing a link beforehand
+	Completed  int                 `json:"completed" yaml:"completed"`   // of those, how many now have all three
+	Entries    []LinkBackfillEntry `json:"entries" yaml:"entries"`
+}
+
+// LinkBackfillEntry is one incomplete archive entry. Filled and Missing
+// name EpisodeFile fields ("url", "magnet_uri", "torrent_url"). SearchURL
+// is the Nyaa search link the entry had as its url, whether or not it was
+// replaced.
+type LinkBackfillEntry struct {
+	CRC32     string   `json:"crc32" yaml:"crc32"`
+	EpisodeID string   `json:"episode_id,omitempty" yaml:"episode_id,omitempty"`
+	Title     string   `json:"title" yaml:"title"`
+	Filled    []string `json:"filled,omitempty" yaml:"filled,omitempty"`
+	Sources   []string `json:"sources,omitempty" yaml:"sources,omitempty"`
+	Missing   []string `json:"missing,omitempty" yaml:"missing,omitempty"`
+	SearchURL string   `json:"search_url,omitempty" yaml:"search_url,omitempty"`
+}
+
+//
+// ===============================
+//   TV SHOW (data/tvshow.{json,yml})
+// ===============================
+//
+
+// TVShow is the show-level metadata consumed by media servers. The
+// descriptive fields come from config.TVShowTemplate; everything below
+// Status is derived from the scraped arcs on every run so it can't drift
+// from the data it describes.
+type TVShow struct {
+	Title        string   `json:"title" yaml:"title"`
+	SortTitle    string   `json:"sorttitle" yaml:"sorttitle"`
+	Genre        []string `json:"genre" yaml:"genre"`
+	Premiered    string   `json:"premiered" yaml:"premiered"`
+	ReleaseDate  string   `json:"releasedate" yaml:"releasedate"`
+	Year         string   `json:"year" yaml:"year"`
+	Plot         string   `json:"plot" yaml:"plot"`
+	CustomRating string   `json:"customrating" yaml:"customrating"`
+
+	Status string `json:"status" yaml:"status"` // "Continuing" | "Ended"
+
+	ArcCount            int    `json:"arc_count" yaml:"arc_count"`
+	EpisodeCount        int    `json:"episode_count" yaml:"episode_count"`
+	LatestRelease       string `json:"latest_release,omitempty" yaml:"latest_release,omitempty"`
+	TotalRuntimeSeconds int    `json:"total_runtime_seconds" yaml:"total_runtime_seconds"`
+}
+
+//
+// ===============================
+//   ANIME CROSS-REFERENCE (data/anime-list.{xml,json})
+// ===============================
+//
+
+// AnimeMapping maps One Pace arcs/episodes back to the original One Piece
+// anime's absolute episode numbers. The XML export mirrors the community
+// anime-lists format; this is its JSON equivalent.
+type AnimeMapping struct {
+	AniDBID int          `json:"anidb_id" yaml:"anidb_id"`
+	TVDBID  string       `json:"tvdb_id,omitempty" yaml:"tvdb_id,omitempty"`
+	Arcs    []ArcMapping `json:"arcs" yaml:"arcs"`
+}
+
+type ArcMapping struct {
+	ArcID string `json:"arc_id,omitempty" yaml:"arc_id,omitempty"`
+	Arc   int    `json:"arc" yaml:"arc"`
+	Title string `json:"title" yaml:"title"`
+
+	AnimeEpisodes     string        `json:"anime_episodes" yaml:"anime_episodes"`
+	AnimeEpisodeRange *ChapterRange `json:"anime_episode_range,omitempty" yaml:"anime_episode_range,omitempty"`
+
+	Episodes []EpisodeMapping `json:"episodes" yaml:"episodes"`
+}
+
+type EpisodeMapping struct {
+	EpisodeID string `json:"episode_id,omitempty" yaml:"episode_id,omitempty"`
+	Episode   int    `json:"episode" yaml:"episode"`
+
+	// AnimeEps is the raw sheet text; AnimeEpisodes is its expansion into
+	// absolute episode numbers, empty when the text couldn't be parsed.
+	AnimeEps      string `json:"anime_eps" yaml:"anime_eps"`
+	AnimeEpisodes []int  `json:"anime_episodes,omitempty" yaml:"anime_episodes,omitempty"`
+}
+
+//
+// ===============================
+//   COVERAGE REPORT (data/reports/coverage.json)
+// ===============================
+//
+
+// CoverageReport compares each arc's declared manga chapters against the
+// chapters its episodes actually cover. See internal/coverage.
+type CoverageReport struct {
+	Arcs []ArcCoverage `json:"arcs" yaml:"arcs"`
+
+	// CrossArcOverlaps are chapters adapted by episodes of more than one
+	// arc. Expected for cover-story arcs, suspicious anywhere else.
+	CrossArcOverlaps []ChapterOverlap `json:"cross_arc_overlaps,omitempty" yaml:"cross_arc_overlaps,omitempty"`
+
+	// Skipped are chapters, up to the latest one adapted, that no episode
+	// of any arc covers.
+	Skipped []ChapterRange `json:"skipped,omitempty" yaml:"skipped,omitempty"`
+}
+
+type ArcCoverage struct {
+	ArcID string `json:"arc_id,omitempty" yaml:"arc_id,omitempty"`
+	Arc   int    `json:"arc" yaml:"arc"`
+	Title string `json:"title" yaml:"title"`
+
+	Declared []ChapterRange `json:"declared,omitempty" yaml:"declared,omitempty"`
+
+	// Gaps are declared chapters no episode of the arc covers.
+	Gaps []ChapterRange `json:"gaps,omitempty" yaml:"gaps,omitempty"`
+	// Overlaps are chapters covered by more than one episode of the arc.
+	Overlaps []ChapterOverlap `json:"overlaps,omitempty" yaml:"overlaps,omitempty"`
+	// OutOfRange are chapters an episode covers outside the declared range.
+	OutOfRange []ChapterRange `json:"out_of_range,omitempty" yaml:"out_of_range,omitempty"`
+
+	// Unparsed lists chapter references (arc or episode) that couldn't be
+	// parsed, so the analysis above may be incomplete.
+	Unparsed []string `json:"unparsed,omitempty" yaml:"unparsed,omitempty"`
+}
+
+// ChapterOverlap is a run of chapters shared by the same set of episodes
+// (within an arc) or arcs (across arcs).
+type ChapterOverlap struct {
+	Chapters   ChapterRange `json:"chapters" yaml:"chapters"`
+	EpisodeIDs []string     `json:"episode_ids,omitempty" yaml:"episode_ids,omitempty"`
+	ArcIDs     []string     `json:"arc_ids,omitempty" yaml:"arc_ids,omitempty"`
+}
+
+//
+// ===============================
+//   RELEASE MATCH REPORT (data/reports/release-matches.json)
+// ===============================
+//
+
+// ReleaseMatchReport summarizes how the releases archive joined to the
+// episode guide: counts per match method, plus the releases that only
+// matched an arc (batches) or matched nothing at all.
+type ReleaseMatchReport struct {
+	ByCRC32    int                `json:"by_crc32" yaml:"by_crc32"`
+	ByTitle    int                `json:"by_title" yaml:"by_title"`
+	ByFilename int                `json:"by_filename" yaml:"by_filename"`
+	ArcOnly    []UnmatchedRelease `json:"arc_only,omitempty" yaml:"arc_only,omitempty"`
+	Unmatched  []UnmatchedRelease `json:"unmatched,omitempty" yaml:"unmatched,omitempty"`
+}
+
+type UnmatchedRelease struct {
+	InfoHash    string    `json:"info_hash" yaml:"info_hash"`
+	Title       string    `json:"title" yaml:"title"`
+	Variant     string    `json:"variant" yaml:"variant"`
+	CRC32       string    `json:"crc32,omitempty" yaml:"crc32,omitempty"`
+	PublishedAt Timestamp `json:"published_at" yaml:"published_at"`
+	ArcID       string    `json:"arc_id,omitempty" yaml:"arc_id,omitempty"`
+}
+
+//
+// ===============================
+//   TRACKER REGISTRY (data/trackers.json)
+// ===============================
+//
+
+// Tracker is one entry of the registry of every tracker seen in a release
+// magnet. Stored magnets drop their trackers, so this is the only record
+// of them.
+type Tracker struct {
+	URL string `json:"url" yaml:"url"`
+	// Releases counts the distinct releases (by infohash) seen announcing
+	// this tracker.
+	Releases int `json:"releases" yaml:"releases"`
+	// Active is false when the tracker is in config.TrackerDenylist;
+	// active trackers are added to magnets on output.
+	Active bool `json:"active" yaml:"active"`
+}
+
+//
+// ===============================
+//   DATE REPORT (data/reports/dates.json)
+// ===============================
+//
+
+// UnparsedDate is a date or timestamp that couldn't be parsed. It's kept
+// verbatim in the data, but can't take part in "newest" comparisons until
+// it's fixed at the source (or parse.Date learns its format).
+type UnparsedDate struct {
+	Source string `json:"source" yaml:"source"` // "episode" | "archive" | "release"
+	ID     string `json:"id" yaml:"id"`         // Episode.ID, CRC32 or InfoHash
+	Title  string `json:"title,omitempty" yaml:"title,omitempty"`
+	Value  string `json:"value" yaml:"value"`
+}
+
+//
+// ===============================
+//   CHANGELOGS + EPISODE HISTORY (data/episode-history.{json,yml})
+// ===============================
+//
+
+// Changelog line categories (ChangelogEntry.Categories).
+const (
+	ChangeSubtitles = "subtitles"
+	ChangeAudio     = "audio"
+	ChangeVideo     = "video" // re-edits, cuts, art fixes, new footage
+	ChangeTiming    = "timing"
+	ChangeTypo      = "typo"
+	ChangeOther     = "other"
+)
+
+// ChangelogEntry is one changelog line, classified best-effort by keyword.
+// A line can fall in several categories ("Blu-ray and dub sync added").
+type ChangelogEntry struct {
+	Text       string   `json:"text" yaml:"text"`
+	Categories []string `json:"categories" yaml:"categories"`
+	// Timecode is the position a re-edit note refers to, e.g. "03:02"
+	// from "03:02 - Microcut".
+	Timecode string `json:"timecode,omitempty" yaml:"timecode,omitempty"`
+}
+
+// EpisodeHistory lists every file an episode has been released as, oldest
+// first, so users can judge whether a re-release is worth re-downloading.
+type EpisodeHistory struct {
+	ArcID     string           `json:"arc_id,omitempty" yaml:"arc_id,omitempty"`
+	EpisodeID string           `json:"episode_id" yaml:"episode_id"`
+	Arc       int              `json:"arc" yaml:"arc"`
+	Episode   int              `json:"episode" yaml:"episode"`
+	Title     string           `json:"title" yaml:"title"`
+	Versions  []EpisodeVersion `json:"versions" yaml:"versions"`
+}
+
+// EpisodeVersion is one file in an EpisodeHistory. Files known only from
+// the releases feed (no sheet entry) have no Released date, and older
+// releases may have no CRC32.
+type EpisodeVersion struct {
+	CRC32       string    `json:"crc32,omitempty" yaml:"crc32,omitempty"`
+	Version     string    `json:"version" yaml:"version"` // "normal" | "extended" | "alternate"
+	Resolution  string    `json:"resolution,omitempty" yaml:"resolution,omitempty"`
+	Released    Date      `json:"released" yaml:"released"`
+	InfoHash    string    `json:"info_hash,omitempty" yaml:"info_hash,omitempty"`
+	PublishedAt Timestamp `json:"published_at" yaml:"published_at"`
+	Status      string    `json:"status,omitempty" yaml:"status,omitempty"`
+	IsCurrent   bool      `json:"is_current" yaml:"is_current"`
+
+	Changelog []ChangelogEntry `json:"changelog,omitempty" yaml:"changelog,omitempty"`
+	// Changes is the union of Changelog's categories.
+	Changes []string `json:"changes,omitempty" yaml:"changes,omitempty"`
+}
+
+//
+// ===============================
+//   EPISODE GUIDE SCRAPE REPORT (data/reports/guide.json)
+// ===============================
+//
+
+// GuideReport records what the episode guide scrape had to guess at or
+// couldn't place, for fixing in the sheets or the config.
+type GuideReport struct {
+	Descriptions DescriptionMatchReport `json:"descriptions" yaml:"descriptions"`
+	// Warnings are problems reading individual arc episode sheets.
+	Warnings []SheetWarning `json:"warnings" yaml:"warnings"`
+	// Layouts are the header rows this run read, arc list first; Drift is
+	// how they differ from the last run's (data/sheet-layouts.json).
+	Layouts []SheetLayout `json:"layouts" yaml:"layouts"`
+	Drift   []LayoutDrift `json:"drift" yaml:"drift"`
+}
+
+// SheetLayout fingerprints one tab's layout: its header row and how many
+// columns its rows use.
+type SheetLayout struct {
+	Sheet       string   `json:"sheet" yaml:"sheet"` // the tab's gid
+	ArcID       string   `json:"arc_id,omitempty" yaml:"arc_id,omitempty"`
+	Title       string   `json:"title" yaml:"title"`
+	Header      []string `json:"header" yaml:"header"`
+	Columns     int      `json:"columns" yaml:"columns"`
+	Fingerprint string   `json:"fingerprint" yaml:"fingerprint"`
+}
+
+// Layout drift kinds (LayoutDrift.Kind).
+const (
+	DriftAdded   = "added"   // a header that wasn't there before
+	DriftRemoved = "removed" // a header that's gone
+	DriftRenamed = "renamed" // a different header in the same position
+	DriftMoved   = "moved"   // the same header in a different position
+	DriftColumns = "columns" // rows use a different number of columns
+)
+
+// LayoutDrift is one change to a tab's layout since the previous run.
+// Column is the 0-based column now (the old one for DriftRemoved, and the
+// new column count for DriftColumns).
+type LayoutDrift struct {
+	Sheet    string `json:"sheet" yaml:"sheet"`
+	ArcID    string `json:"arc_id,omitempty" yaml:"arc_id,omitempty"`
+	Title    string `json:"title" yaml:"title"`
+	Kind     string `json:"kind" yaml:"kind"`
+	Column   int    `json:"column" yaml:"column"`
+	Header   string `json:"header,omitempty" yaml:"header,omitempty"`
+	Previous string `json:"previous,omitempty" yaml:"previous,omitempty"` // the old header, or old column/count
+	Message  string `json:"message" yaml:"message"`
+}
+
+// SheetWarning is a row (or, with Row 0, a whole sheet) of an arc's episode
+// sheet that couldn't be read as expected. The rest of the sheet is still
+// used.
+type SheetWarning struct {
+	ArcID   string `json:"arc_id" yaml:"arc_id"`
+	Sheet   string `json:"sheet" yaml:"sheet"`                     // the tab's gid
+	Row     int    `json:"row,omitempty" yaml:"row,omitempty"`     // 1-based, as shown in the sheet
+	Field   string `json:"field,omitempty" yaml:"field,omitempty"` // the column, e.g. "crc32"
+	Value   string `json:"value,omitempty" yaml:"value,omitempty"`
+	Message string `json:"message" yaml:"message"`
+}
+
+// DescriptionMatchReport covers joining the descriptions sheet's rows to
+// arcs by arc title. Exact matches aren't listed.
+type DescriptionMatchReport struct {
+	// Inexact lists arcs joined by a normalized, alias or fuzzy title
+	// match; worth a look, and worth an alias if fuzzy.
+	Inexact []DescriptionMatch `json:"inexact" yaml:"inexact"`
+	// UnmatchedArcs have no description rows; their episodes keep the
+	// episode sheet's placeholder titles ("Romance Dawn 03").
+	UnmatchedArcs []DescriptionMatch `json:"unmatched_arcs" yaml:"unmatched_arcs"`
+	// UnmatchedRows are description-sheet arc titles no arc matched.
+	UnmatchedRows []string `json:"unmatched_rows" yaml:"unmatched_rows"`
+	// MissingEpisodes are EpisodeIDs of matched arcs with no row of their own.
+	MissingEpisodes []string `json:"missing_episodes" yaml:"missing_episodes"`
+}
+
+type DescriptionMatch struct {
+	ArcID     string `json:"arc_id" yaml:"arc_id"`
+	Arc       string `json:"arc" yaml:"arc"`                                   // the arc's title
+	Rows      string `json:"rows,omitempty" yaml:"rows,omitempty"`             // the description sheet's arc title
+	MatchedBy string `json:"matched_by,omitempty" yaml:"matched_by,omitempty"` // see parse.MatchTitle
 }
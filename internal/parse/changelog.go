@@ -0,0 +1,72 @@
+package parse
+
+import (
+	"regexp"
+	"strings"
+
+	"metadata-service/internal/model"
+)
+
+// changelogTimecodeRe matches a leading timecode, as in "03:02 - Microcut"
+// or "16:24.567 - ...".
+var changelogTimecodeRe = regexp.MustCompile(`^(\d{1,2}:\d{2}(?::\d{2})?(?:\.\d+)?)\s*[-–—:]\s*`)
+
+// changelogRules map keywords to categories, checked in order; a line
+// gets every category whose pattern matches.
+var changelogRules = []struct {
+	category string
+	re       *regexp.Regexp
+}{
+	{model.ChangeTypo, regexp.MustCompile(`(?i)\b(typos?|spelling|misspell\w*|file ?name)\b`)},
+	{model.ChangeSubtitles, regexp.MustCompile(`(?i)\b(subtitles?|subs|karaoke|captions?)\b`)},
+	{model.ChangeAudio, regexp.MustCompile(`(?i)\b(dub|audio|volume|sound|sfx|ost|voices?|music)\b`)},
+	// Syncing to new footage is a video change; syncing subs/audio is timing.
+	{model.ChangeTiming, regexp.MustCompile(`(?i)\b(timing|desync\w*|sync issue|out of sync|sync(ed)? (subs|subtitles|audio))\b`)},
+	{model.ChangeVideo, regexp.MustCompile(`(?i)\b(cuts?|microcuts?|removed|restored|reverted|shorten\w*|extended|added back|moved|art fix\w*|colou?rs?|logo|pans?|blu-?ray|footage|frames?|shots?|scenes?|re-?edit\w*|sped up|slowed|pacing|runtime|breathing room|ending|ED|opening|OP|episode title|\d{3,4}p)\b`)},
+}
+
+// ChangelogLine classifies a release changelog line by keyword. A leading
+// timecode marks an edit at that point in the episode, so it also counts
+// as a video change. Lines matching nothing are ChangeOther.
+func ChangelogLine(s string) model.ChangelogEntry {
+	entry := model.ChangelogEntry{Text: strings.TrimSpace(s)}
+	body := entry.Text
+	if m := changelogTimecodeRe.FindStringSubmatch(body); m != nil {
+		entry.Timecode = m[1]
+		body = body[len(m[0]):]
+	}
+
+	for _, rule := range changelogRules {
+		if rule.re.MatchString(body) {
+			entry.Categories = append(entry.Categories, rule.category)
+		}
+	}
+	if entry.Timecode != "" && !hasCategory(entry.Categories, model.ChangeVideo) {
+		entry.Categories = append(entry.Categories, model.ChangeVideo)
+	}
+	if len(entry.Categories) == 0 {
+		entry.Categories = []string{model.ChangeOther}
+	}
+	return entry
+}
+
+// Changelog classifies every line of a release changelog. Returns nil for
+// an empty changelog.
+func Changelog(lines []string) []model.ChangelogEntry {
+	var out []model.ChangelogEntry
+	for _, line := range lines {
+		if strings.TrimSpace(line) != "" {
+			out = append(out, ChangelogLine(line))
+		}
+	}
+	return out
+}
+
+func hasCategory(categories []string, c string) bool {
+	for _, have := range categories {
+		if have == c {
+			return true
+		}
+	}
+	return false
+}
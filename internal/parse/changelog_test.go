@@ -0,0 +1,36 @@
+package parse
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestChangelogLine(t *testing.T) {
+	cases := []struct {
+		in         string
+		categories []string
+		timecode   string
+	}{
+		{"Added Arabic and German subs.", []string{"subtitles"}, ""},
+		{"Added English dub.", []string{"audio"}, ""},
+		{"Fixed a typo in the English subs.", []string{"typo", "subtitles"}, ""},
+		{"Rerelease with proper file name.", []string{"typo"}, ""},
+		{"Synced to Bluray footage.", []string{"video"}, ""},
+		{"Fixed a sync issue with the German ED and Spanish subtitles.", []string{"subtitles", "timing", "video"}, ""},
+		{"03:02 - Microcut", []string{"video"}, "03:02"},
+		{"09:22 - Changed this a bit", []string{"video"}, "09:22"},
+		{"13:06 - Cut this down, removed the OST starting then ending right away", []string{"audio", "video"}, "13:06"},
+		{"Changed the episode title to chapter 145's \"Inherited Will\"", []string{"video"}, ""},
+		{"Some unrelated note", []string{"other"}, ""},
+	}
+	for _, c := range cases {
+		got := ChangelogLine(c.in)
+		if !reflect.DeepEqual(got.Categories, c.categories) || got.Timecode != c.timecode {
+			t.Errorf("ChangelogLine(%q) = %v %q, want %v %q", c.in, got.Categories, got.Timecode, c.categories, c.timecode)
+		}
+	}
+
+	if got := Changelog([]string{"", "  "}); got != nil {
+		t.Errorf("Changelog of blank lines = %v, want nil", got)
+	}
+}
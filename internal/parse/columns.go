@@ -0,0 +1,96 @@
+package parse
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Column declares one field read from a sheet by its header rather than
+// its position: the header names it's known by (compared by HeaderKey,
+// so "No." matches "no" and "Time Saved (%)" matches "time saved %") and
+// whether the sheet is unusable without it.
+type Column struct {
+	Field    string
+	Headers  []string
+	Required bool
+}
+
+// ColumnMap maps each Column.Field found in a header row to its index.
+type ColumnMap map[string]int
+
+// HeaderKey normalizes a sheet header for matching: lowercased, with "%"
+// and "#" spelled out and any other punctuation and whitespace collapsed
+// to single spaces.
+func HeaderKey(s string) string {
+	s = strings.NewReplacer("%", " percent ", "#", " number ").Replace(strings.ToLower(s))
+	return strings.Join(strings.FieldsFunc(s, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}), " ")
+}
+
+// MapColumns finds each column's index in header. A field takes the first
+// header cell matching any of its names. The error names every required
+// column that's missing; the map is still returned with what was found.
+func MapColumns(header []string, columns []Column) (ColumnMap, error) {
+	index := make(map[string]int, len(header))
+	for i, h := range header {
+		if key := HeaderKey(h); key != "" {
+			if _, seen := index[key]; !seen {
+				index[key] = i
+			}
+		}
+	}
+
+	m := make(ColumnMap, len(columns))
+	var missing []string
+	for _, col := range columns {
+		found := false
+		for _, name := range col.Headers {
+			if i, ok := index[HeaderKey(name)]; ok {
+				m[col.Field] = i
+				found = true
+				break
+			}
+		}
+		if !found && col.Required {
+			missing = append(missing, fmt.Sprintf("%s (%q)", col.Field, col.Headers[0]))
+		}
+	}
+	if len(missing) > 0 {
+		return m, fmt.Errorf("missing required column(s): %s", strings.Join(missing, ", "))
+	}
+	return m, nil
+}
+
+// Unmapped returns the header cells no column claimed, by index, skipping
+// blank headers.
+func (m ColumnMap) Unmapped(header []string) map[int]string {
+	claimed := make(map[int]bool, len(m))
+	for _, i := range m {
+		claimed[i] = true
+	}
+	out := make(map[int]string)
+	for i, h := range header {
+		if h = strings.TrimSpace(h); h != "" && !claimed[i] {
+			out[i] = h
+		}
+	}
+	return out
+}
+
+// LayoutFingerprint identifies a sheet layout: its header cells, exactly
+// as written, and the number of columns its rows use. Any edit to either
+// changes it.
+func LayoutFingerprint(header []string, columns int) string {
+	h := sha256.New()
+	for _, cell := range header {
+		h.Write([]byte(cell))
+		h.Write([]byte{0x1f})
+	}
+	h.Write([]byte(strconv.Itoa(columns)))
+	return hex.EncodeToString(h.Sum(nil))[:16]
+}
@@ -0,0 +1,72 @@
+package parse
+
+import "testing"
+
+func TestHeaderKey(t *testing.T) {
+	cases := []struct{ in, want string }{
+		{"No.", "no"},
+		{"  Time Saved (%) ", "time saved percent"},
+		{"Time Saved (mins)", "time saved mins"},
+		{"# of Chapters", "number of chapters"},
+		{"Audio\nLanguages", "audio languages"},
+		{"", ""},
+	}
+	for _, c := range cases {
+		if got := HeaderKey(c.in); got != c.want {
+			t.Errorf("HeaderKey(%q) = %q, want %q", c.in, got, c.want)
+		}
+	}
+}
+
+func TestMapColumns(t *testing.T) {
+	columns := []Column{
+		{Field: "number", Headers: []string{"No."}, Required: true},
+		{Field: "title", Headers: []string{"Arcs", "Arc"}, Required: true},
+		{Field: "resolution", Headers: []string{"Resolution"}},
+		{Field: "saved_percent", Headers: []string{"Time Saved (%)"}},
+	}
+
+	m, err := MapColumns([]string{"No.", "New Column", "ARC", "time saved %"}, columns)
+	if err != nil {
+		t.Fatalf("MapColumns: %v", err)
+	}
+	want := ColumnMap{"number": 0, "title": 2, "saved_percent": 3}
+	if len(m) != len(want) {
+		t.Fatalf("MapColumns = %v, want %v", m, want)
+	}
+	for field, i := range want {
+		if m[field] != i {
+			t.Errorf("%s at %d, want %d", field, m[field], i)
+		}
+	}
+
+	unmapped := m.Unmapped([]string{"No.", "New Column", "ARC", "time saved %", " "})
+	if len(unmapped) != 1 || unmapped[1] != "New Column" {
+		t.Errorf("Unmapped = %v, want only New Column", unmapped)
+	}
+
+	if _, err := MapColumns([]string{"No.", "Resolution"}, columns); err == nil {
+		t.Error("MapColumns without the title column: want an error")
+	}
+}
+
+func TestLayoutFingerprint(t *testing.T) {
+	base := LayoutFingerprint([]string{"No.", "Arcs", ""}, 3)
+	if base != LayoutFingerprint([]string{"No.", "Arcs", ""}, 3) {
+		t.Error("fingerprint isn't stable")
+	}
+	for _, tc := range []struct {
+		header  []string
+		columns int
+	}{
+		{[]string{"No.", "Arc", ""}, 3},  // renamed
+		{[]string{"Arcs", "No.", ""}, 3}, // reordered
+		{[]string{"No.", "Arcs"}, 3},     // header cell dropped
+		{[]string{"No.", "Arcs", ""}, 4}, // column added
+		{[]string{"No.Arcs", "", ""}, 3}, // cells run together
+	} {
+		if LayoutFingerprint(tc.header, tc.columns) == base {
+			t.Errorf("LayoutFingerprint(%q, %d) = base fingerprint", tc.header, tc.columns)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package parse
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"metadata-service/internal/model"
+)
+
+// dateYMDRe matches a year-first numeric date with any of the separators
+// the sheet has used: "2025.05.03", "2025-05-03", "2025/5/3".
+var dateYMDRe = regexp.MustCompile(`^(\d{4})[.\-/](\d{1,2})[.\-/](\d{1,2})$`)
+
+// dateLayouts are the spelled-out forms, tried in order.
+var dateLayouts = []string{
+	"January 2, 2006",
+	"Jan 2, 2006",
+	"January 2 2006",
+	"Jan 2 2006",
+	"2 January 2006",
+	"2 Jan 2006",
+	time.RFC3339,
+}
+
+// datePlaceholders are cell values that mean "not released yet" rather
+// than a malformed date.
+var datePlaceholders = map[string]bool{"": true, "to be released": true, "tbr": true, "tba": true, "tbd": true, "n/a": true, "-": true}
+
+// Date parses a release date from the sheet. Year-first numeric dates with
+// ".", "-" or "/" separators and spelled-out months ("May 3, 2025",
+// "3 May 2025") are recognized; ambiguous day/month-first numeric forms
+// ("03/05/2025") are not guessed at. ok is false when s is neither a date
+// nor a placeholder such as "To Be Released"; the returned Date then keeps
+// s as Raw.
+func Date(s string) (d model.Date, ok bool) {
+	s = strings.Join(strings.Fields(s), " ")
+	if IsDatePlaceholder(s) {
+		return model.Date{Raw: s}, true
+	}
+
+	if m := dateYMDRe.FindStringSubmatch(s); m != nil {
+		y, _ := strconv.Atoi(m[1])
+		mo, _ := strconv.Atoi(m[2])
+		day, _ := strconv.Atoi(m[3])
+		t := time.Date(y, time.Month(mo), day, 0, 0, 0, 0, time.UTC)
+		// time.Date normalizes "2025-02-30" to March; reject it instead.
+		if t.Year() == y && int(t.Month()) == mo && t.Day() == day {
+			return model.Date{Time: t}, true
+		}
+		return model.Date{Raw: s}, false
+	}
+
+	for _, layout := range dateLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return model.NewDate(t.Year(), t.Month(), t.Day()), true
+		}
+	}
+	return model.Date{Raw: s}, false
+}
+
+// IsDatePlaceholder reports whether s is an empty or "not released yet"
+// value rather than a date.
+func IsDatePlaceholder(s string) bool {
+	return datePlaceholders[strings.ToLower(strings.TrimSpace(s))]
+}
+
+// Timestamp parses an RFC 3339 timestamp such as the releases feed's
+// "2022-09-26T12:00:00.000Z", or an RSS (RFC 1123) date such as Nyaa's
+// "Mon, 26 Sep 2022 12:00:00 -0000". ok is false (and Raw keeps s)
+// otherwise.
+func Timestamp(s string) (ts model.Timestamp, ok bool) {
+	s = strings.TrimSpace(s)
+	for _, layout := range []string{time.RFC3339, time.RFC1123Z, time.RFC1123} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return model.Timestamp{Time: t}, true
+		}
+	}
+	return model.Timestamp{Raw: s}, s == ""
+}
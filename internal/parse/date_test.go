@@ -0,0 +1,86 @@
+package parse
+
+import (
+	"encoding/json"
+	"testing"
+
+	"metadata-service/internal/model"
+)
+
+func TestDate(t *testing.T) {
+	cases := []struct {
+		in     string
+		want   string // Date.String()
+		known  bool
+		wantOK bool
+	}{
+		{"2025.05.03", "2025-05-03", true, true},
+		{"2025-05-03", "2025-05-03", true, true},
+		{"2025-5-3", "2025-05-03", true, true},
+		{"2025/05/03", "2025-05-03", true, true},
+		{"May 3, 2025", "2025-05-03", true, true},
+		{"3 May 2025", "2025-05-03", true, true},
+		{"To Be Released", "To Be Released", false, true},
+		{"", "", false, true},
+		{"2025-02-30", "2025-02-30", false, false},
+		{"03/05/2025", "03/05/2025", false, false},
+		{"soon", "soon", false, false},
+	}
+	for _, c := range cases {
+		d, ok := Date(c.in)
+		if d.String() != c.want || d.Known() != c.known || ok != c.wantOK {
+			t.Errorf("Date(%q) = %q known=%v ok=%v, want %q known=%v ok=%v",
+				c.in, d.String(), d.Known(), ok, c.want, c.known, c.wantOK)
+		}
+	}
+}
+
+func TestTimestamp(t *testing.T) {
+	cases := []struct {
+		in     string
+		want   string // Timestamp.String()
+		wantOK bool
+	}{
+		{"2022-09-26T12:00:00.000Z", "2022-09-26T12:00:00.000Z", true},
+		{"Mon, 26 Sep 2022 12:00:00 -0000", "2022-09-26T12:00:00.000Z", true},
+		{"Mon, 26 Sep 2022 14:00:00 +0200", "2022-09-26T12:00:00.000Z", true},
+		{"", "", true},
+		{"yesterday", "yesterday", false},
+	}
+	for _, c := range cases {
+		ts, ok := Timestamp(c.in)
+		if ts.String() != c.want || ok != c.wantOK {
+			t.Errorf("Timestamp(%q) = %q ok=%v, want %q ok=%v", c.in, ts.String(), ok, c.want, c.wantOK)
+		}
+	}
+}
+
+func TestDateJSONRoundTrip(t *testing.T) {
+	type row struct {
+		Released    model.Date      `json:"released"`
+		PublishedAt model.Timestamp `json:"published_at"`
+	}
+	for _, in := range []string{
+		`{"released":"2025-05-03","published_at":"2022-09-26T12:00:00.000Z"}`,
+		`{"released":"To Be Released","published_at":""}`,
+	} {
+		var r row
+		if err := json.Unmarshal([]byte(in), &r); err != nil {
+			t.Fatalf("unmarshal %s: %v", in, err)
+		}
+		out, err := json.Marshal(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(out) != in {
+			t.Errorf("round trip: got %s, want %s", out, in)
+		}
+	}
+
+	older, _ := Date("2024-01-01")
+	newer, _ := Date("2024-06-01")
+	unknown, _ := Date("soon")
+	if !newer.After(older) || older.After(newer) || unknown.After(older) || !older.After(unknown) {
+		t.Error("Date.After ordering is wrong")
+	}
+}
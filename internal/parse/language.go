@@ -0,0 +1,122 @@
+package parse
+
+import (
+	"regexp"
+	"strings"
+
+	"metadata-service/internal/model"
+)
+
+// languageCodes are the ISO 639-1 codes the episode guide uses (or
+// plausibly could), mapped to their BCP 47 primary subtag. Anything else is
+// reported back as unknown rather than passed through.
+var languageCodes = map[string]string{
+	"AR": "ar", "BG": "bg", "CS": "cs", "DA": "da", "DE": "de", "EL": "el",
+	"EN": "en", "ES": "es", "FI": "fi", "FR": "fr", "HE": "he", "HI": "hi",
+	"HR": "hr", "HU": "hu", "ID": "id", "IT": "it", "JA": "ja", "KO": "ko",
+	"MS": "ms", "NL": "nl", "NO": "no", "PL": "pl", "PT": "pt", "RO": "ro",
+	"RU": "ru", "SK": "sk", "SR": "sr", "SV": "sv", "TH": "th", "TR": "tr",
+	"UK": "uk", "VI": "vi", "ZH": "zh",
+}
+
+var (
+	// languageTokenRe splits "PT-BR (1-5)" into code, region and the
+	// parenthesised annotation.
+	languageTokenRe = regexp.MustCompile(`^([A-Za-z]{2})(?:-([A-Za-z]{2}|[0-9]{3}))?\s*(?:\(([^)]*)\))?$`)
+
+	// episodeListRe matches an episode annotation such as "1-5,8-9" or
+	// "1-4, 31-" (open-ended).
+	episodeListRe = regexp.MustCompile(`^[0-9][0-9,\s-]*$`)
+)
+
+// Languages parses an arc's audio/subtitle language list, e.g.
+// "EN, EN(CC), AR(1-7), PT-BR", into BCP 47 tagged entries. "(CC)" marks
+// closed captions; a numeric annotation is kept as the episodes the
+// language covers. Tokens it can't make sense of are returned in unknown
+// (verbatim) and left out of langs.
+func Languages(s string) (langs []model.Language, unknown []string) {
+	for _, token := range splitTopLevel(s) {
+		m := languageTokenRe.FindStringSubmatch(token)
+		if m == nil {
+			unknown = append(unknown, token)
+			continue
+		}
+		primary, ok := languageCodes[strings.ToUpper(m[1])]
+		if !ok {
+			unknown = append(unknown, token)
+			continue
+		}
+
+		lang := model.Language{Tag: primary}
+		if m[2] != "" {
+			lang.Tag += "-" + strings.ToUpper(m[2])
+		}
+
+		switch note := strings.TrimSpace(m[3]); {
+		case note == "":
+		case strings.EqualFold(note, "CC"):
+			lang.ClosedCaptions = true
+		case episodeListRe.MatchString(note):
+			lang.Episodes = note
+		default:
+			unknown = append(unknown, token)
+			continue
+		}
+
+		langs = append(langs, lang)
+	}
+	return langs, unknown
+}
+
+// splitTopLevel splits s on commas that aren't inside parentheses, so
+// "ES(1-5,8-9), FR" yields "ES(1-5,8-9)" and "FR". Empty items are dropped.
+func splitTopLevel(s string) []string {
+	var out []string
+	depth, start := 0, 0
+	flush := func(end int) {
+		if item := strings.TrimSpace(s[start:end]); item != "" {
+			out = append(out, item)
+		}
+	}
+	for i, r := range s {
+		switch r {
+		case '(':
+			depth++
+		case ')':
+			if depth > 0 {
+				depth--
+			}
+		case ',':
+			if depth == 0 {
+				flush(i)
+				start = i + 1
+			}
+		}
+	}
+	flush(len(s))
+	return out
+}
+
+// localizedColumnRe matches a per-language sheet column header such as
+// "title_en", "description_pt-br" or "Title (ES)".
+var localizedColumnRe = regexp.MustCompile(`(?i)^(title|description)\s*[_\s(-]\s*([a-z]{2})(?:[-_]([a-z]{2}))?\)?$`)
+
+// LocalizedColumn recognizes a per-language column header, returning the
+// field ("title" or "description") and its BCP 47 language tag:
+// "description_pt-br" -> "description", "pt-BR". ok is false for any other
+// header, including an unknown language code.
+func LocalizedColumn(header string) (field, tag string, ok bool) {
+	m := localizedColumnRe.FindStringSubmatch(strings.TrimSpace(header))
+	if m == nil {
+		return "", "", false
+	}
+	primary, known := languageCodes[strings.ToUpper(m[2])]
+	if !known {
+		return "", "", false
+	}
+	tag = primary
+	if m[3] != "" {
+		tag += "-" + strings.ToUpper(m[3])
+	}
+	return strings.ToLower(m[1]), tag, true
+}
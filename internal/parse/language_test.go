@@ -0,0 +1,71 @@
+package parse
+
+import (
+	"reflect"
+	"testing"
+
+	"metadata-service/internal/model"
+)
+
+func TestLanguages(t *testing.T) {
+	cases := []struct {
+		in      string
+		want    []model.Language
+		unknown []string
+	}{
+		{
+			"JA, EN, ES",
+			[]model.Language{{Tag: "ja"}, {Tag: "en"}, {Tag: "es"}},
+			nil,
+		},
+		{
+			"EN, EN(CC), PT-BR",
+			[]model.Language{{Tag: "en"}, {Tag: "en", ClosedCaptions: true}, {Tag: "pt-BR"}},
+			nil,
+		},
+		{
+			"JA,EN (1-4, 31-)",
+			[]model.Language{{Tag: "ja"}, {Tag: "en", Episodes: "1-4, 31-"}},
+			nil,
+		},
+		{
+			"EN, ES(1-5,8-9), XX, FR(dub only)",
+			[]model.Language{{Tag: "en"}, {Tag: "es", Episodes: "1-5,8-9"}},
+			[]string{"XX", "FR(dub only)"},
+		},
+		{"", nil, nil},
+	}
+
+	for _, c := range cases {
+		got, unknown := Languages(c.in)
+		if !reflect.DeepEqual(got, c.want) {
+			t.Errorf("Languages(%q) = %+v, want %+v", c.in, got, c.want)
+		}
+		if !reflect.DeepEqual(unknown, c.unknown) {
+			t.Errorf("Languages(%q) unknown = %q, want %q", c.in, unknown, c.unknown)
+		}
+	}
+}
+
+func TestLocalizedColumn(t *testing.T) {
+	cases := []struct {
+		in         string
+		field, tag string
+		ok         bool
+	}{
+		{"title_en", "title", "en", true},
+		{"description_en", "description", "en", true},
+		{"Description_PT-BR", "description", "pt-BR", true},
+		{"title_pt_br", "title", "pt-BR", true},
+		{"Title (ES)", "title", "es", true},
+		{"title_xx", "", "", false},
+		{"arc_title", "", "", false},
+		{"saga", "", "", false},
+	}
+	for _, c := range cases {
+		field, tag, ok := LocalizedColumn(c.in)
+		if field != c.field || tag != c.tag || ok != c.ok {
+			t.Errorf("LocalizedColumn(%q) = %q, %q, %v, want %q, %q, %v", c.in, field, tag, ok, c.field, c.tag, c.ok)
+		}
+	}
+}
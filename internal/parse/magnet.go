@@ -0,0 +1,202 @@
+package parse
+
+import (
+	"encoding/base32"
+	"encoding/hex"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"metadata-service/internal/model"
+)
+
+var (
+	// filenameLeadingTagRe and filenameTrailingTagRe peel bracketed tags off
+	// either end of a release filename.
+	filenameLeadingTagRe  = regexp.MustCompile(`^\[([^\]]*)\]\s*`)
+	filenameTrailingTagRe = regexp.MustCompile(`\s*\[([^\]]*)\]$`)
+
+	filenameExtRe      = regexp.MustCompile(`\.([A-Za-z0-9]{2,4})$`)
+	filenameCRCRe      = regexp.MustCompile(`^[0-9A-Fa-f]{8}$`)
+	filenameChaptersRe = regexp.MustCompile(`^\d[\d\s,\-]*$`)
+
+	// nyaaPathRe matches a Nyaa torrent's view page or .torrent path.
+	nyaaPathRe = regexp.MustCompile(`^/(?:view/(\d+)|download/(\d+)\.torrent)$`)
+)
+
+// Magnet parses a magnet URI into its infohashes (v1 "btih", hex or
+// base32; v2 "btmh"), display name, exact length and trackers, plus the
+// fields encoded in the display name (see ReleaseFilename). Duplicate
+// trackers are dropped. Returns nil for anything that isn't a magnet URI.
+func Magnet(uri string) *model.Magnet {
+	raw, ok := strings.CutPrefix(strings.TrimSpace(uri), "magnet:?")
+	if !ok {
+		return nil
+	}
+	values, err := url.ParseQuery(raw)
+	if err != nil {
+		return nil
+	}
+
+	m := &model.Magnet{DisplayName: values.Get("dn")}
+	for _, xt := range values["xt"] {
+		switch {
+		case strings.HasPrefix(xt, "urn:btih:"):
+			m.InfoHash = btihHex(strings.TrimPrefix(xt, "urn:btih:"))
+		case strings.HasPrefix(xt, "urn:btmh:"):
+			m.InfoHashV2 = strings.ToLower(strings.TrimPrefix(xt, "urn:btmh:"))
+		}
+	}
+	if xl, err := strconv.ParseInt(values.Get("xl"), 10, 64); err == nil && xl > 0 {
+		m.ExactLength = xl
+	}
+	seen := make(map[string]bool)
+	for _, tr := range values["tr"] {
+		if tr != "" && !seen[tr] {
+			seen[tr] = true
+			m.Trackers = append(m.Trackers, tr)
+		}
+	}
+	m.File = ReleaseFilename(m.DisplayName)
+	return m
+}
+
+// btihHex normalizes a v1 infohash to lowercase hex; magnets may carry it
+// as 40 hex digits or 32 base32 characters. Returns "" if it's neither.
+func btihHex(s string) string {
+	switch len(s) {
+	case 40:
+		if _, err := hex.DecodeString(s); err == nil {
+			return strings.ToLower(s)
+		}
+	case 32:
+		if b, err := base32.StdEncoding.DecodeString(strings.ToUpper(s)); err == nil {
+			return hex.EncodeToString(b)
+		}
+	}
+	return ""
+}
+
+// NyaaLinks returns both of a Nyaa torrent's links given either one: its
+// view page ("https://nyaa.si/view/1583366") and its .torrent
+// ("https://nyaa.si/download/1583366.torrent"). Returns "", "" for any
+// other URL.
+func NyaaLinks(u string) (view, torrent string) {
+	parsed, err := url.Parse(strings.TrimSpace(u))
+	if err != nil || !isNyaaHost(parsed.Host) {
+		return "", ""
+	}
+	m := nyaaPathRe.FindStringSubmatch(parsed.Path)
+	if m == nil {
+		return "", ""
+	}
+	id := m[1] + m[2]
+	return "https://nyaa.si/view/" + id, "https://nyaa.si/download/" + id + ".torrent"
+}
+
+// NyaaSearch reports whether u is a Nyaa search ("https://nyaa.si/?q=..."),
+// which older archive entries hold in place of a link to the torrent, and
+// the infohash it searches for when the query is one.
+func NyaaSearch(u string) (infoHash string, ok bool) {
+	parsed, err := url.Parse(strings.TrimSpace(u))
+	if err != nil || !isNyaaHost(parsed.Host) || strings.Trim(parsed.Path, "/") != "" || !parsed.Query().Has("q") {
+		return "", false
+	}
+	return btihHex(strings.TrimSpace(parsed.Query().Get("q"))), true
+}
+
+func isNyaaHost(host string) bool {
+	return host == "nyaa.si" || host == "www.nyaa.si"
+}
+
+// ReleaseFilename reads the fields out of a One Pace release filename:
+//
+//	"[One Pace][129-132] Drum Island 01 [1080p][FD2B4F32].mkv"
+//	  -> chapters "129-132", title "Drum Island 01" (arc "Drum Island",
+//	     episode 1), 1080p, CRC FD2B4F32, extension "mkv"
+//
+// Tags it doesn't recognise are ignored. Returns nil for an empty name.
+func ReleaseFilename(name string) *model.ReleaseFilename {
+	s := strings.TrimSpace(name)
+	if s == "" {
+		return nil
+	}
+	f := &model.ReleaseFilename{}
+
+	if m := filenameExtRe.FindStringSubmatch(s); m != nil {
+		f.Extension = strings.ToLower(m[1])
+		s = strings.TrimSuffix(s, m[0])
+	}
+
+	for {
+		m := filenameLeadingTagRe.FindStringSubmatch(s)
+		if m == nil {
+			break
+		}
+		if tag := strings.TrimSpace(m[1]); filenameChaptersRe.MatchString(tag) {
+			f.Chapters = tag
+			f.ChapterRange = ChapterRange(tag)
+		}
+		s = s[len(m[0]):]
+	}
+	for {
+		m := filenameTrailingTagRe.FindStringSubmatch(s)
+		if m == nil {
+			break
+		}
+		tag := strings.TrimSpace(m[1])
+		switch {
+		case filenameCRCRe.MatchString(tag):
+			f.CRC32 = strings.ToUpper(tag)
+		case FilenameResolution("["+tag+"]") != "":
+			f.Resolution = FilenameResolution("[" + tag + "]")
+		}
+		s = strings.TrimSuffix(s, m[0])
+	}
+
+	f.Title = strings.TrimSpace(s)
+	if f.Title != "" {
+		f.Arc, f.Episode = ReleaseTitle(f.Title)
+	}
+	return f
+}
+
+// CompactMagnet reduces a magnet URI to its exact topics ("xt") and display
+// name, dropping trackers and everything else; FullMagnet adds trackers
+// back. Returns uri unchanged if it isn't a magnet URI.
+func CompactMagnet(uri string) string {
+	raw, ok := strings.CutPrefix(strings.TrimSpace(uri), "magnet:?")
+	if !ok {
+		return uri
+	}
+	values, err := url.ParseQuery(raw)
+	if err != nil {
+		return uri
+	}
+
+	var params []string
+	for _, xt := range values["xt"] {
+		params = append(params, "xt="+xt)
+	}
+	if dn := values.Get("dn"); dn != "" {
+		params = append(params, "dn="+url.QueryEscape(dn))
+	}
+	return "magnet:?" + strings.Join(params, "&")
+}
+
+// FullMagnet rebuilds a magnet URI from its compact form with the given
+// trackers, replacing any it already lists.
+func FullMagnet(uri string, trackers []string) string {
+	compact := CompactMagnet(uri)
+	if !strings.HasPrefix(compact, "magnet:?") {
+		return uri
+	}
+	var b strings.Builder
+	b.WriteString(compact)
+	for _, tr := range trackers {
+		b.WriteString("&tr=")
+		b.WriteString(url.QueryEscape(tr))
+	}
+	return b.String()
+}
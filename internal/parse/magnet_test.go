@@ -0,0 +1,115 @@
+package parse
+
+import (
+	"reflect"
+	"testing"
+
+	"metadata-service/internal/model"
+)
+
+func TestMagnet(t *testing.T) {
+	uri := "magnet:?xt=urn:btih:00D22C441E261AE3005E32736F2154B1156F5C48" +
+		"&xt=urn:btmh:1220ABCDEF" +
+		"&dn=%5BOne+Pace%5D%5B129-132%5D+Drum+Island+01+%5B1080p%5D%5BFD2B4F32%5D.mkv" +
+		"&xl=734003200" +
+		"&tr=http%3A%2F%2Fnyaa.tracker.wf%3A7777%2Fannounce" +
+		"&tr=udp%3A%2F%2Fopen.stealth.si%3A80%2Fannounce" +
+		"&tr=http%3A%2F%2Fnyaa.tracker.wf%3A7777%2Fannounce"
+
+	ep := 1
+	want := &model.Magnet{
+		InfoHash:    "00d22c441e261ae3005e32736f2154b1156f5c48",
+		InfoHashV2:  "1220abcdef",
+		DisplayName: "[One Pace][129-132] Drum Island 01 [1080p][FD2B4F32].mkv",
+		ExactLength: 734003200,
+		Trackers:    []string{"http://nyaa.tracker.wf:7777/announce", "udp://open.stealth.si:80/announce"},
+		File: &model.ReleaseFilename{
+			Chapters:     "129-132",
+			ChapterRange: &model.ChapterRange{Start: 129, End: 132},
+			Title:        "Drum Island 01",
+			Arc:          "Drum Island",
+			Episode:      &ep,
+			Resolution:   "1080p",
+			CRC32:        "FD2B4F32",
+			Extension:    "mkv",
+		},
+	}
+	if got := Magnet(uri); !reflect.DeepEqual(got, want) {
+		t.Errorf("Magnet() = %+v, want %+v", got, want)
+	}
+
+	// Base32 infohashes are normalized to hex.
+	if got := Magnet("magnet:?xt=urn:btih:ADJCYRA6EYNOGAC6GJZW6IKUWEKW6XCI"); got == nil || got.InfoHash != "00d22c441e261ae3005e32736f2154b1156f5c48" {
+		t.Errorf("base32 btih: got %+v", got)
+	}
+
+	for _, bad := range []string{"", "https://nyaa.si/view/1", "magnet:?%zz"} {
+		if got := Magnet(bad); got != nil {
+			t.Errorf("Magnet(%q) = %+v, want nil", bad, got)
+		}
+	}
+}
+
+func TestReleaseFilename(t *testing.T) {
+	f := ReleaseFilename("[One Pace] Arlong Park [720p]")
+	if f == nil || f.Title != "Arlong Park" || f.Arc != "Arlong Park" || f.Episode != nil || f.Resolution != "720p" || f.CRC32 != "" || f.Extension != "" {
+		t.Errorf("batch name: got %+v", f)
+	}
+	if f := ReleaseFilename(""); f != nil {
+		t.Errorf("empty name: got %+v, want nil", f)
+	}
+}
+
+func TestCompactAndFullMagnet(t *testing.T) {
+	full := "magnet:?xt=urn:btih:00d22c441e261ae3005e32736f2154b1156f5c48" +
+		"&dn=%5BOne+Pace%5D+Drum+Island+01.mkv" +
+		"&tr=udp%3A%2F%2Ftracker.coppersurfer.tk%3A6969&xl=100"
+	compact := "magnet:?xt=urn:btih:00d22c441e261ae3005e32736f2154b1156f5c48&dn=%5BOne+Pace%5D+Drum+Island+01.mkv"
+
+	if got := CompactMagnet(full); got != compact {
+		t.Errorf("CompactMagnet() = %q, want %q", got, compact)
+	}
+	want := compact + "&tr=udp%3A%2F%2Fopen.stealth.si%3A80%2Fannounce"
+	if got := FullMagnet(full, []string{"udp://open.stealth.si:80/announce"}); got != want {
+		t.Errorf("FullMagnet() = %q, want %q", got, want)
+	}
+	if got := FullMagnet(want, []string{"udp://open.stealth.si:80/announce"}); got != want {
+		t.Errorf("FullMagnet() isn't idempotent: %q", got)
+	}
+	if got := CompactMagnet("https://nyaa.si"); got != "https://nyaa.si" {
+		t.Errorf("CompactMagnet(non-magnet) = %q", got)
+	}
+}
+
+func TestNyaaLinks(t *testing.T) {
+	const view, torrent = "https://nyaa.si/view/1583366", "https://nyaa.si/download/1583366.torrent"
+	for _, in := range []string{view, torrent, "http://nyaa.si/view/1583366"} {
+		if v, tr := NyaaLinks(in); v != view || tr != torrent {
+			t.Errorf("NyaaLinks(%q) = %q, %q", in, v, tr)
+		}
+	}
+	for _, in := range []string{"", "https://nyaa.si/?q=abc", "https://example.com/view/1", "https://nyaa.si/user/onepace"} {
+		if v, tr := NyaaLinks(in); v != "" || tr != "" {
+			t.Errorf("NyaaLinks(%q) = %q, %q, want nothing", in, v, tr)
+		}
+	}
+}
+
+func TestNyaaSearch(t *testing.T) {
+	cases := []struct {
+		in       string
+		infoHash string
+		ok       bool
+	}{
+		{"https://nyaa.si/?q=00D22C441E261AE3005E32736F2154B1156F5C48", "00d22c441e261ae3005e32736f2154b1156f5c48", true},
+		{"https://nyaa.si/?f=0&c=0_0&q=One+Pace+FD2B4F32", "", true},
+		{"https://nyaa.si/view/1583366", "", false},
+		{"https://example.com/?q=00d22c441e261ae3005e32736f2154b1156f5c48", "", false},
+		{"", "", false},
+	}
+	for _, c := range cases {
+		if h, ok := NyaaSearch(c.in); h != c.infoHash || ok != c.ok {
+			t.Errorf("NyaaSearch(%q) = %q, %v, want %q, %v", c.in, h, ok, c.infoHash, c.ok)
+		}
+	}
+}
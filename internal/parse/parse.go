@@ -5,6 +5,7 @@
 package parse
 
 import (
+	"regexp"
 	"strconv"
 	"strings"
 
@@ -73,17 +74,93 @@ func LengthSeconds(s string) int {
 	}
 }
 
-// NormalizeVariant maps the releases feed's "regular"/"extended" vocabulary
-// onto the episode file's "normal"/"extended" vocabulary so the two can be
-// compared/joined directly. Unrecognized values pass through unchanged.
-func NormalizeVariant(s string) string {
-	switch strings.ToLower(strings.TrimSpace(s)) {
-	case "regular":
-		return "normal"
-	case "normal", "extended":
-		return strings.ToLower(strings.TrimSpace(s))
+// runtimeUnitsRe matches a spelled-out duration such as "12h 30m",
+// "5 hrs 2 mins" or "45 min".
+var runtimeUnitsRe = regexp.MustCompile(`(?i)^(?:(\d+)\s*h(?:(?:ou)?rs?)?)?\s*(?:(\d+)\s*m(?:in(?:ute)?s?)?)?$`)
+
+// Runtime parses a total runtime from the arc list into seconds: "h:mm:ss"
+// or "mm:ss" (see LengthSeconds), a bare number of minutes ("754" or
+// "754.5"), or hours and minutes spelled out ("12h 34m"). Returns nil if
+// the string is empty or doesn't parse.
+func Runtime(s string) *int {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil
+	}
+	if strings.Contains(s, ":") {
+		if secs := LengthSeconds(s); secs > 0 {
+			return &secs
+		}
+		return nil
+	}
+	if mins, err := strconv.ParseFloat(s, 64); err == nil && mins >= 0 {
+		secs := int(mins*60 + 0.5)
+		return &secs
+	}
+	if m := runtimeUnitsRe.FindStringSubmatch(s); m != nil && (m[1] != "" || m[2] != "") {
+		h, _ := strconv.Atoi(m[1])
+		mins, _ := strconv.Atoi(m[2])
+		secs := h*3600 + mins*60
+		return &secs
+	}
+	return nil
+}
+
+// byteUnits are the size suffixes ByteSize accepts, by lowercase name.
+var byteUnits = map[string]float64{
+	"b": 1, "bytes": 1,
+	"kib": 1 << 10, "mib": 1 << 20, "gib": 1 << 30, "tib": 1 << 40,
+	"kb": 1e3, "mb": 1e6, "gb": 1e9, "tb": 1e12,
+}
+
+// ByteSize parses a human-readable file size such as Nyaa's "1.2 GiB"
+// into bytes. Binary (KiB) and decimal (KB) units are told apart. Returns
+// nil if the string is empty or doesn't parse.
+func ByteSize(s string) *int64 {
+	fields := strings.Fields(s)
+	if len(fields) == 0 || len(fields) > 2 {
+		return nil
+	}
+	unit := "b"
+	if len(fields) == 2 {
+		unit = strings.ToLower(fields[1])
+	}
+	mult, ok := byteUnits[unit]
+	n, err := strconv.ParseFloat(fields[0], 64)
+	if !ok || err != nil || n < 0 {
+		return nil
+	}
+	v := int64(n*mult + 0.5)
+	return &v
+}
+
+// releaseCutRe finds the cut named in a release title, e.g.
+// "Arlong Park 05 Extended Cut" or "Skypiea 25 Alternate Cut (G-8)".
+var releaseCutRe = regexp.MustCompile(`(?i)\b(extended|alternate)(?:\s+cut)?\b`)
+
+// ReleaseVariant splits a releases-feed category into the cut the release
+// is (variant: "normal", "extended" or "alternate", in EpisodeFile.Version's
+// vocabulary) and its lifecycle status (model.ReleaseActive,
+// ReleaseOutdated or ReleaseAlternate). The feed files superseded releases
+// under "outdated" instead of their cut, so for those the cut is read from
+// the title.
+func ReleaseVariant(category, title string) (variant, status string) {
+	cut := "normal"
+	if m := releaseCutRe.FindStringSubmatch(title); m != nil {
+		cut = strings.ToLower(m[1])
+	}
+
+	switch strings.ToLower(strings.TrimSpace(category)) {
+	case "", "regular", "normal":
+		return "normal", model.ReleaseActive
+	case "extended":
+		return "extended", model.ReleaseActive
+	case "alternate":
+		return "alternate", model.ReleaseAlternate
+	case "outdated":
+		return cut, model.ReleaseOutdated
 	default:
-		return s
+		return cut, model.ReleaseActive
 	}
 }
 
@@ -56,19 +56,20 @@ func TestLengthSeconds(t *testing.T) {
 	}
 }
 
-func TestNormalizeVariant(t *testing.T) {
-	cases := []struct{ in, want string }{
-		{"regular", "normal"},
-		{"extended", "extended"},
-		{"normal", "normal"},
-		{"Regular", "normal"},
-		{"", ""},
-		{"weird", "weird"},
+func TestReleaseVariant(t *testing.T) {
+	cases := []struct{ category, title, variant, status string }{
+		{"regular", "Wano 26", "normal", model.ReleaseActive},
+		{"extended", "Arlong Park 05 Extended Cut", "extended", model.ReleaseActive},
+		{"alternate", "Skypiea 25 Alternate Cut (G-8)", "alternate", model.ReleaseAlternate},
+		{"outdated", "Arlong Park 05 Extended Cut", "extended", model.ReleaseOutdated},
+		{"outdated", "Skypiea 25 Alternate Cut (G-8)", "alternate", model.ReleaseOutdated},
+		{"outdated", "Wano 26", "normal", model.ReleaseOutdated},
+		{"", "Wano 26", "normal", model.ReleaseActive},
 	}
-
 	for _, c := range cases {
-		if got := NormalizeVariant(c.in); got != c.want {
-			t.Errorf("NormalizeVariant(%q) = %q, want %q", c.in, got, c.want)
+		variant, status := ReleaseVariant(c.category, c.title)
+		if variant != c.variant || status != c.status {
+			t.Errorf("ReleaseVariant(%q, %q) = %q, %q, want %q, %q", c.category, c.title, variant, status, c.variant, c.status)
 		}
 	}
 }
@@ -96,3 +97,51 @@ func TestIntVal(t *testing.T) {
 		t.Errorf("IntVal(garbage) = %v, want nil", got)
 	}
 }
+
+func TestRuntime(t *testing.T) {
+	cases := []struct {
+		in   string
+		want int // -1 for nil
+	}{
+		{"12:34:56", 45296},
+		{"45:00", 2700},
+		{"754", 45240},
+		{"754.5", 45270},
+		{"12h 34m", 45240},
+		{"5 hrs 2 mins", 18120},
+		{"45 min", 2700},
+		{"", -1},
+		{"garbage", -1},
+	}
+	for _, c := range cases {
+		got := Runtime(c.in)
+		if (got == nil) != (c.want == -1) || (got != nil && *got != c.want) {
+			t.Errorf("Runtime(%q) = %v, want %d", c.in, got, c.want)
+		}
+	}
+}
+
+func TestByteSize(t *testing.T) {
+	cases := []struct {
+		in   string
+		want int64 // -1 for nil
+	}{
+		{"1.2 GiB", 1288490189},
+		{"350.5 MiB", 367525888},
+		{"1 KB", 1000},
+		{"512", 512},
+		{"2 gib", 2 << 30},
+		{"", -1},
+		{"1.2 GiBs", -1},
+		{"big", -1},
+	}
+	for _, c := range cases {
+		got := ByteSize(c.in)
+		switch {
+		case c.want < 0 && got != nil:
+			t.Errorf("ByteSize(%q) = %d, want nil", c.in, *got)
+		case c.want >= 0 && (got == nil || *got != c.want):
+			t.Errorf("ByteSize(%q) = %v, want %d", c.in, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package parse
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"metadata-service/internal/model"
+)
+
+var (
+	// refPrefixRe matches a "Ch."/"Ep." style prefix, but only when a number
+	// follows, so "Episode of East Blue" stays a named special.
+	refPrefixRe = regexp.MustCompile(`(?i)^(ch|chs|chapters?|ep|eps|episodes?)\.?\s*(\d.*)$`)
+
+	// refPartRe splits a trailing "(Intro)" annotation off a segment.
+	refPartRe = regexp.MustCompile(`^(.*?)\s*\(([^)]*)\)$`)
+
+	// refNumberRe matches a single number or an "a-b" range, optionally
+	// followed by a note such as "236-262 cover stories".
+	refNumberRe = regexp.MustCompile(`^(\d+)(?:\s*-\s*(\d+))?(?:\s+([A-Za-z].*))?$`)
+
+	// refNumberedSpecialRe matches a named special followed by a number or
+	// range, such as "Straw Hat Theater 1-5".
+	refNumberedSpecialRe = regexp.MustCompile(`^([A-Za-z][^0-9]*?)\s+(\d+)(?:\s*-\s*(\d+))?$`)
+)
+
+// refPlaceholders are cell values that mean "nothing here yet" rather than
+// the name of a special.
+var refPlaceholders = map[string]bool{"tba": true, "tbd": true, "n/a": true, "na": true, "none": true, "?": true, "-": true}
+
+// References parses a chapter or anime-episode reference string into
+// typed segments. defaultKind (model.SegmentMangaChapter or
+// model.SegmentAnimeEpisode) applies to bare numbers; a "Ch."/"Ep." prefix
+// overrides it for that element and the ones after it, and non-numeric
+// names become specials:
+//
+//	"1 - 4, 19"                              -> 1-4, 19
+//	"Ep. 45, 48-53"                          -> ep 45, ep 48-53
+//	"Episode of East Blue, Ep. 312 (Intro)"  -> special, ep 312 part "Intro"
+//	"236-262 cover stories"                  -> 236-262 part "cover stories"
+//	"Straw Hat Theater 1-5"                  -> special 1-5
+//
+// Anything else (reversed ranges, stray punctuation) is returned verbatim in
+// Unparsed. Returns nil for an empty string.
+func References(s, defaultKind string) *model.References {
+	s = strings.NewReplacer("\u2013", "-", "\u2014", "-").Replace(strings.TrimSpace(s))
+	if s == "" {
+		return nil
+	}
+
+	refs := &model.References{}
+	kind := defaultKind
+	for _, token := range splitTopLevel(s) {
+		body := token
+
+		if m := refPrefixRe.FindStringSubmatch(body); m != nil {
+			if strings.HasPrefix(strings.ToLower(m[1]), "c") {
+				kind = model.SegmentMangaChapter
+			} else {
+				kind = model.SegmentAnimeEpisode
+			}
+			body = m[2]
+		}
+
+		part := ""
+		if m := refPartRe.FindStringSubmatch(body); m != nil {
+			body, part = strings.TrimSpace(m[1]), strings.TrimSpace(m[2])
+		}
+
+		if m := refNumberRe.FindStringSubmatch(body); m != nil {
+			start, end, ok := refBounds(m[1], m[2])
+			if !ok {
+				refs.Unparsed = append(refs.Unparsed, token)
+				continue
+			}
+			if m[3] != "" {
+				part = strings.TrimSpace(strings.TrimSpace(m[3]) + " " + part)
+			}
+			refs.Segments = append(refs.Segments, model.Segment{Kind: kind, Start: start, End: end, Part: part})
+			continue
+		}
+
+		if m := refNumberedSpecialRe.FindStringSubmatch(body); m != nil && isSpecialName(m[1]) {
+			start, end, ok := refBounds(m[2], m[3])
+			if !ok {
+				refs.Unparsed = append(refs.Unparsed, token)
+				continue
+			}
+			refs.Segments = append(refs.Segments, model.Segment{Kind: model.SegmentSpecial, Name: m[1], Start: start, End: end, Part: part})
+			continue
+		}
+
+		if isSpecialName(body) {
+			refs.Segments = append(refs.Segments, model.Segment{Kind: model.SegmentSpecial, Name: body, Part: part})
+			continue
+		}
+
+		refs.Unparsed = append(refs.Unparsed, token)
+	}
+
+	return refs
+}
+
+// refBounds converts the matched start (and optional end) of a range,
+// rejecting reversed ranges such as "10-5".
+func refBounds(startStr, endStr string) (start, end int, ok bool) {
+	start, _ = strconv.Atoi(startStr)
+	end = start
+	if endStr != "" {
+		end, _ = strconv.Atoi(endStr)
+	}
+	return start, end, end >= start
+}
+
+// isSpecialName reports whether s looks like the name of a special ("Episode
+// of Sabo"): it starts with a letter, has no digits, and isn't a
+// placeholder like "TBA".
+func isSpecialName(s string) bool {
+	if s == "" || refPlaceholders[strings.ToLower(s)] {
+		return false
+	}
+	first := s[0]
+	if !(first >= 'A' && first <= 'Z' || first >= 'a' && first <= 'z') {
+		return false
+	}
+	return !strings.ContainsAny(s, "0123456789")
+}
@@ -0,0 +1,58 @@
+package parse
+
+import (
+	"reflect"
+	"testing"
+
+	"metadata-service/internal/model"
+)
+
+func TestReferences(t *testing.T) {
+	const (
+		ch      = model.SegmentMangaChapter
+		ep      = model.SegmentAnimeEpisode
+		special = model.SegmentSpecial
+	)
+	cases := []struct {
+		in          string
+		defaultKind string
+		want        *model.References
+	}{
+		{"1 - 4, 19", ep, &model.References{Segments: []model.Segment{
+			{Kind: ep, Start: 1, End: 4}, {Kind: ep, Start: 19, End: 19},
+		}}},
+		{"Ch. 1", ep, &model.References{Segments: []model.Segment{
+			{Kind: ch, Start: 1, End: 1},
+		}}},
+		{"42,22", ch, &model.References{Segments: []model.Segment{
+			{Kind: ch, Start: 42, End: 42}, {Kind: ch, Start: 22, End: 22},
+		}}},
+		{"Ep.590—592, 594", ch, &model.References{Segments: []model.Segment{
+			{Kind: ep, Start: 590, End: 592}, {Kind: ep, Start: 594, End: 594},
+		}}},
+		{"Episode of East Blue, Ep. 312 (Intro)", ep, &model.References{Segments: []model.Segment{
+			{Kind: special, Name: "Episode of East Blue"}, {Kind: ep, Start: 312, End: 312, Part: "Intro"},
+		}}},
+		{"Ep. 492-493,495-496, Episode of Sabo", ep, &model.References{Segments: []model.Segment{
+			{Kind: ep, Start: 492, End: 493}, {Kind: ep, Start: 495, End: 496}, {Kind: special, Name: "Episode of Sabo"},
+		}}},
+		{"236-262 cover stories", ch, &model.References{Segments: []model.Segment{
+			{Kind: ch, Start: 236, End: 262, Part: "cover stories"},
+		}}},
+		{"Straw Hat Theater 1-5, Strong World (movie 10)", ep, &model.References{Segments: []model.Segment{
+			{Kind: special, Name: "Straw Hat Theater", Start: 1, End: 5},
+			{Kind: special, Name: "Strong World", Part: "movie 10"},
+		}}},
+		{"Ch. 10-5, TBA, 7", ch, &model.References{
+			Segments: []model.Segment{{Kind: ch, Start: 7, End: 7}},
+			Unparsed: []string{"Ch. 10-5", "TBA"},
+		}},
+		{"", ch, nil},
+	}
+
+	for _, c := range cases {
+		if got := References(c.in, c.defaultKind); !reflect.DeepEqual(got, c.want) {
+			t.Errorf("References(%q) = %+v, want %+v", c.in, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package parse
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// resolutionRe matches a vertical resolution such as "1080p", "[720P]" or a
+// bare "480".
+var resolutionRe = regexp.MustCompile(`(?i)\b(\d{3,4})p?\b`)
+
+// Resolution normalizes a single resolution value ("1080P", "[720p]",
+// "480") to the "<height>p" form. Returns "" if s holds no resolution.
+func Resolution(s string) string {
+	m := resolutionRe.FindStringSubmatch(s)
+	if m == nil {
+		return ""
+	}
+	return m[1] + "p"
+}
+
+// Resolutions splits an arc-wide resolution string such as "720p,1080p" or
+// "720p / 1080p" into normalized values, dropping duplicates.
+func Resolutions(s string) []string {
+	var out []string
+	seen := make(map[string]bool)
+	for _, m := range resolutionRe.FindAllStringSubmatch(s, -1) {
+		r := m[1] + "p"
+		if !seen[r] {
+			seen[r] = true
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
+// ResolutionHeight returns the pixel height of a normalized resolution
+// ("1080p" -> 1080), or 0 if unknown. Used to rank resolutions.
+func ResolutionHeight(s string) int {
+	n, err := strconv.Atoi(strings.TrimSuffix(s, "p"))
+	if err != nil {
+		return 0
+	}
+	return n
+}
+
+// filenameResolutionRe matches the bracketed resolution tag in a release
+// filename, e.g. "[One Pace][129-132] Drum Island 01 [1080p][FD2B4F32].mkv".
+var filenameResolutionRe = regexp.MustCompile(`(?i)\[(\d{3,4})p\]`)
+
+// FilenameResolution extracts the resolution tag from a release filename.
+// Only the bracketed "[1080p]" form counts, so chapter ranges and CRCs in
+// the same name aren't mistaken for one. Returns "" if there's none.
+func FilenameResolution(name string) string {
+	m := filenameResolutionRe.FindStringSubmatch(name)
+	if m == nil {
+		return ""
+	}
+	return m[1] + "p"
+}
@@ -0,0 +1,51 @@
+package parse
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestResolution(t *testing.T) {
+	cases := []struct{ in, want string }{
+		{"1080p", "1080p"},
+		{"[720P]", "720p"},
+		{"480", "480p"},
+		{"", ""},
+		{"HD", ""},
+	}
+	for _, c := range cases {
+		if got := Resolution(c.in); got != c.want {
+			t.Errorf("Resolution(%q) = %q, want %q", c.in, got, c.want)
+		}
+	}
+}
+
+func TestResolutions(t *testing.T) {
+	cases := []struct {
+		in   string
+		want []string
+	}{
+		{"1080p", []string{"1080p"}},
+		{"720p,1080p", []string{"720p", "1080p"}},
+		{"720p / 1080p / 720p", []string{"720p", "1080p"}},
+		{"", nil},
+	}
+	for _, c := range cases {
+		if got := Resolutions(c.in); !reflect.DeepEqual(got, c.want) {
+			t.Errorf("Resolutions(%q) = %q, want %q", c.in, got, c.want)
+		}
+	}
+}
+
+func TestFilenameResolution(t *testing.T) {
+	cases := []struct{ in, want string }{
+		{"[One Pace][129-132] Drum Island 01 [1080p][FD2B4F32].mkv", "1080p"},
+		{"[One Pace][1011-1012] Wano 61 [720p][1EF3F26C].mkv", "720p"},
+		{"[One Pace][129-132] Drum Island 01 [FD2B4F32].mkv", ""},
+	}
+	for _, c := range cases {
+		if got := FilenameResolution(c.in); got != c.want {
+			t.Errorf("FilenameResolution(%q) = %q, want %q", c.in, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,245 @@
+package parse
+
+import (
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+var (
+	// titleNoteRe strips a trailing parenthesised note, e.g. "(G-8)" or
+	// "(April Fools 2025)".
+	titleNoteRe = regexp.MustCompile(`\s*\([^)]*\)$`)
+
+	// titleCutRe strips a trailing cut name ("Extended Cut", or just
+	// "Alternate" in filenames); the feed's category already says which
+	// variant a release is.
+	titleCutRe = regexp.MustCompile(`(?i)\s+(extended|alternate|alt)(\s+cut)?$`)
+
+	// titleActRe matches a batch release of one act of an arc, e.g.
+	// "Wano Act 1". The number is the act, not an episode.
+	titleActRe = regexp.MustCompile(`(?i)^(.*?)\s+act\s+\d+$`)
+
+	// titleEpisodeRe matches "<Arc Title> <episode number>".
+	titleEpisodeRe = regexp.MustCompile(`^(.*?)\s+(\d{1,3})$`)
+)
+
+// ReleaseTitle splits a releases-feed title into the arc title and the
+// episode number it encodes:
+//
+//	"Arlong Park 05 Extended Cut"     -> "Arlong Park", 5
+//	"Skypiea 25 Alternate Cut (G-8)"  -> "Skypiea", 25
+//	"The Trials of Koby-Meppo"        -> "The Trials of Koby-Meppo", nil
+//	"Wano Act 1"                      -> "Wano", nil
+//
+// episode is nil when the title has no episode number — a whole-arc (or
+// whole-act) batch, or a single-episode arc. Returns "" for an empty title.
+func ReleaseTitle(title string) (arc string, episode *int) {
+	s := strings.TrimSpace(title)
+	s = titleNoteRe.ReplaceAllString(s, "")
+	s = titleCutRe.ReplaceAllString(s, "")
+
+	if m := titleActRe.FindStringSubmatch(s); m != nil {
+		return m[1], nil
+	}
+	if m := titleEpisodeRe.FindStringSubmatch(s); m != nil {
+		n, _ := strconv.Atoi(m[2])
+		return m[1], &n
+	}
+	return s, nil
+}
+
+// TitleKey normalizes an arc title for matching: lowercased, with
+// punctuation dropped and whitespace collapsed, so "Koby-Meppo" and
+// "koby meppo" compare equal.
+func TitleKey(s string) string {
+	var b strings.Builder
+	space := false
+	for _, r := range strings.ToLower(s) {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			if space && b.Len() > 0 {
+				b.WriteByte(' ')
+			}
+			b.WriteRune(r)
+			space = false
+		case r == '\'' || r == '’':
+			// "Buggy's" -> "buggys"
+		default:
+			space = true
+		}
+	}
+	return b.String()
+}
+
+// Title match methods (see MatchTitle).
+const (
+	TitleMatchExact      = "exact"
+	TitleMatchNormalized = "normalized"
+	TitleMatchAlias      = "alias"
+	TitleMatchFuzzy      = "fuzzy"
+)
+
+// titleMatchRank orders the match methods from strongest to weakest.
+var titleMatchRank = map[string]int{
+	TitleMatchExact:      0,
+	TitleMatchNormalized: 1,
+	TitleMatchAlias:      2,
+	TitleMatchFuzzy:      3,
+}
+
+// titleFuzzyThreshold is the minimum similarity (1 - edit distance / longer
+// length, on space-free TitleKeys) for a fuzzy title match.
+const titleFuzzyThreshold = 0.8
+
+// TitleAliases resolves alternative spellings of arc titles to the title
+// they stand for. Build it with NewTitleAliases.
+type TitleAliases map[string]string
+
+// NewTitleAliases builds TitleAliases from an alias -> title table such as
+// config.ArcTitleAliases.
+func NewTitleAliases(aliases map[string]string) TitleAliases {
+	a := make(TitleAliases, len(aliases))
+	for alias, title := range aliases {
+		a[compactTitleKey(alias)] = compactTitleKey(title)
+	}
+	return a
+}
+
+// Canonical returns the key two spellings of the same arc title share:
+// its TitleKey without spaces, with an alias replaced by its title's key.
+func (a TitleAliases) Canonical(title string) string {
+	key := compactTitleKey(title)
+	if c, ok := a[key]; ok {
+		return c
+	}
+	return key
+}
+
+// CompareTitles reports the strongest way s and t name the same arc:
+//
+//   - exact: identical strings
+//   - normalized: equal TitleKeys, also ignoring spaces ("Fish-Man Island"
+//     and "Fishman Island")
+//   - alias: equal Canonical keys
+//   - fuzzy: similar enough ("Whiskey Peak" and "Whisky Peak")
+//
+// similarity is 1 for the first three, and by is "" when they don't match.
+func CompareTitles(s, t string, aliases TitleAliases) (by string, similarity float64) {
+	switch key := compactTitleKey(s); {
+	case s == t:
+		return TitleMatchExact, 1
+	case key == compactTitleKey(t):
+		return TitleMatchNormalized, 1
+	case aliases.Canonical(s) == aliases.Canonical(t):
+		return TitleMatchAlias, 1
+	default:
+		similarity = titleSimilarity(key, compactTitleKey(t))
+		if similarity >= titleFuzzyThreshold {
+			return TitleMatchFuzzy, similarity
+		}
+		return "", similarity
+	}
+}
+
+// TitleMatch is a title MatchTitles paired a name with.
+type TitleMatch struct {
+	Title string
+	By    string // see CompareTitles
+}
+
+// MatchTitles pairs each of names with at most one of titles, and each
+// title with at most one name. Every pair is compared (see CompareTitles)
+// and they're assigned best first: stronger methods before weaker ones,
+// more similar fuzzy matches before less similar ones, then in the order
+// given. So an earlier name can't take a title that matches a later name
+// better. A fuzzy pair that's tied with another still-open pair for the
+// same name or title is ambiguous and left out. Returns the matches by
+// index into names.
+func MatchTitles(names, titles []string, aliases TitleAliases) map[int]TitleMatch {
+	type pair struct {
+		name, title int
+		by          string
+		similarity  float64
+	}
+	var pairs []pair
+	for i, n := range names {
+		for j, t := range titles {
+			if by, similarity := CompareTitles(n, t, aliases); by != "" {
+				pairs = append(pairs, pair{i, j, by, similarity})
+			}
+		}
+	}
+	sort.SliceStable(pairs, func(a, b int) bool {
+		pa, pb := pairs[a], pairs[b]
+		if titleMatchRank[pa.by] != titleMatchRank[pb.by] {
+			return titleMatchRank[pa.by] < titleMatchRank[pb.by]
+		}
+		return pa.similarity > pb.similarity
+	})
+
+	matches := make(map[int]TitleMatch)
+	taken := make(map[int]bool)
+	open := func(p pair) bool {
+		_, named := matches[p.name]
+		return !named && !taken[p.title]
+	}
+	for _, p := range pairs {
+		if !open(p) {
+			continue
+		}
+		if p.by == TitleMatchFuzzy && slices.ContainsFunc(pairs, func(q pair) bool {
+			return q != p && q.by == TitleMatchFuzzy && q.similarity == p.similarity &&
+				(q.name == p.name || q.title == p.title) && open(q)
+		}) {
+			continue
+		}
+		matches[p.name] = TitleMatch{Title: titles[p.title], By: p.by}
+		taken[p.title] = true
+	}
+	return matches
+}
+
+// MatchTitle finds the entry of titles that names the same arc as s, by
+// the strongest method any of them matches with (see CompareTitles), and
+// reports the method. Returns "", "" when nothing matches, or when the
+// best fuzzy candidate is tied with another.
+func MatchTitle(s string, titles []string, aliases TitleAliases) (match, by string) {
+	m := MatchTitles([]string{s}, titles, aliases)[0]
+	return m.Title, m.By
+}
+
+// compactTitleKey is TitleKey without spaces.
+func compactTitleKey(s string) string {
+	return strings.ReplaceAll(TitleKey(s), " ", "")
+}
+
+// titleSimilarity scores two keys from 0 (nothing alike) to 1 (equal) by
+// Levenshtein distance relative to the longer key.
+func titleSimilarity(a, b string) float64 {
+	ra, rb := []rune(a), []rune(b)
+	longest := max(len(ra), len(rb))
+	if longest == 0 {
+		return 0
+	}
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return 1 - float64(prev[len(rb)])/float64(longest)
+}
@@ -0,0 +1,88 @@
+package parse
+
+import "testing"
+
+func TestReleaseTitle(t *testing.T) {
+	cases := []struct {
+		in      string
+		arc     string
+		episode int // -1 for no episode number
+	}{
+		{"Arlong Park 05 Extended Cut", "Arlong Park", 5},
+		{"Wano 26", "Wano", 26},
+		{"The Adventures of Buggy's Crew 00", "The Adventures of Buggy's Crew", 0},
+		{"Skypiea 25 Alternate Cut (G-8)", "Skypiea", 25},
+		{"Skypiea 25 Alternate (G-8)", "Skypiea", 25},
+		{"Warship Island 01 (April Fools 2025)", "Warship Island", 1},
+		{"The Trials of Koby-Meppo", "The Trials of Koby-Meppo", -1},
+		{"Wano Act 1", "Wano", -1},
+		{"", "", -1},
+	}
+	for _, c := range cases {
+		arc, ep := ReleaseTitle(c.in)
+		got := -1
+		if ep != nil {
+			got = *ep
+		}
+		if arc != c.arc || got != c.episode {
+			t.Errorf("ReleaseTitle(%q) = %q, %d, want %q, %d", c.in, arc, got, c.arc, c.episode)
+		}
+	}
+}
+
+func TestTitleKey(t *testing.T) {
+	cases := []struct{ in, want string }{
+		{"The Trials of Koby-Meppo", "the trials of koby meppo"},
+		{"The Adventures of Buggy's Crew", "the adventures of buggys crew"},
+		{"  If You Could Go Anywhere...  The Adventures ", "if you could go anywhere the adventures"},
+		{"", ""},
+	}
+	for _, c := range cases {
+		if got := TitleKey(c.in); got != c.want {
+			t.Errorf("TitleKey(%q) = %q, want %q", c.in, got, c.want)
+		}
+	}
+}
+
+func TestMatchTitle(t *testing.T) {
+	titles := []string{"Romance Dawn", "Whisky Peak", "Fishman Island", "Alabasta", "Water Seven", "Wano"}
+	aliases := NewTitleAliases(map[string]string{"Arabasta": "Alabasta"})
+	cases := []struct{ in, match, by string }{
+		{"Romance Dawn", "Romance Dawn", TitleMatchExact},
+		{"romance  dawn!", "Romance Dawn", TitleMatchNormalized},
+		{"Fish-Man Island", "Fishman Island", TitleMatchNormalized},
+		{"Arabasta", "Alabasta", TitleMatchAlias},
+		{"Whiskey Peak", "Whisky Peak", TitleMatchFuzzy},
+		{"Water 7", "", ""},
+		{"Egghead", "", ""},
+		{"", "", ""},
+	}
+	for _, c := range cases {
+		match, by := MatchTitle(c.in, titles, aliases)
+		if match != c.match || by != c.by {
+			t.Errorf("MatchTitle(%q) = %q by %q, want %q by %q", c.in, match, by, c.match, c.by)
+		}
+	}
+}
+
+func TestMatchTitlesRanksAllPairs(t *testing.T) {
+	// "Whisky Peak" comes first and matches the row too, but "Whiskey
+	// Peak" matches it better.
+	names := []string{"Whisky Peak", "Whiskey Peak", "Arabasta"}
+	titles := []string{"Whiskey Peaks", "Alabasta"}
+	aliases := NewTitleAliases(map[string]string{"Arabasta": "Alabasta"})
+
+	got := MatchTitles(names, titles, aliases)
+	want := map[int]TitleMatch{
+		1: {"Whiskey Peaks", TitleMatchFuzzy},
+		2: {"Alabasta", TitleMatchAlias},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("MatchTitles = %+v, want %+v", got, want)
+	}
+	for i, m := range want {
+		if got[i] != m {
+			t.Errorf("MatchTitles[%q] = %+v, want %+v", names[i], got[i], m)
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strings"
+
+	"metadata-service/internal/export"
+	"metadata-service/internal/model"
+	"metadata-service/internal/torznab"
+)
+
+// dataFiles are the exported files a Dataset is built from. Their mtimes
+// are what the reload watcher polls.
+var dataFiles = []string{
+	"arcs.json",
+	"episodes.json",
+	"episodes-current.json",
+	"releases.json",
+	"trackers.json",
+}
+
+// optionalDataFiles are the dataFiles a Dataset can be built without (see
+// export.LoadTrackers).
+var optionalDataFiles = map[string]bool{
+	"trackers.json": true,
+}
+
+// Dataset is an immutable, indexed snapshot of the exported data. The
+// server swaps in a whole new Dataset on reload rather than mutating one.
+type Dataset struct {
+	Arcs     []model.Arc
+	Sagas    []model.Saga
+	Episodes export.EpisodesArchive
+	Current  map[string]model.CurrentEpisode
+	Releases export.ReleasesArchive
+
+	arcsByID     map[string]*model.Arc
+	episodesByID map[string]*model.Episode
+	history      map[string]model.EpisodeHistory
+	torznab      *torznab.Indexer
+
+	// stamp is the combined mtime of dataFiles at load time.
+	stamp string
+}
+
+// LoadDataset reads and indexes the exported data in dir.
+func LoadDataset(dir string) (*Dataset, error) {
+	stamp, err := dataStamp(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	d := &Dataset{stamp: stamp}
+	if d.Arcs, err = export.LoadArcs(dir + "/arcs.json"); err != nil {
+		return nil, err
+	}
+	if d.Episodes, err = export.LoadEpisodesArchive(dir + "/episodes.json"); err != nil {
+		return nil, err
+	}
+	if d.Current, err = export.LoadCurrentEpisodes(dir + "/episodes-current.json"); err != nil {
+		return nil, err
+	}
+	if d.Releases, err = export.LoadReleasesArchive(dir + "/releases.json"); err != nil {
+		return nil, err
+	}
+
+	// The archives store compact magnets; serve them with the registry's
+	// trackers.
+	trackers, err := export.LoadTrackers(dir + "/trackers.json")
+	if err != nil {
+		return nil, err
+	}
+	for crc, entry := range d.Episodes {
+		entry.File = export.FileWithTrackers(entry.File, trackers)
+		d.Episodes[crc] = entry
+	}
+	for hash, r := range d.Releases {
+		d.Releases[hash] = export.ReleaseWithTrackers(r, trackers)
+	}
+
+	d.arcsByID = make(map[string]*model.Arc, len(d.Arcs))
+	d.episodesByID = make(map[string]*model.Episode)
+	for i := range d.Arcs {
+		arc := &d.Arcs[i]
+		d.arcsByID[arc.ID] = arc
+		for j := range arc.Episodes {
+			d.episodesByID[arc.Episodes[j].ID] = &arc.Episodes[j]
+		}
+	}
+	d.Sagas = export.BuildSagas(d.Arcs)
+	d.history = export.BuildEpisodeHistory(d.Arcs, d.Episodes, d.Releases)
+	d.torznab = torznab.New(d.Episodes, d.Releases, trackers)
+
+	return d, nil
+}
+
+// dataStamp fingerprints dataFiles by modification time and size, so a
+// re-export is noticed without re-reading every file. A missing optional
+// file is part of the fingerprint too, so its appearing is noticed.
+func dataStamp(dir string) (string, error) {
+	var b strings.Builder
+	for _, name := range dataFiles {
+		info, err := os.Stat(dir + "/" + name)
+		if errors.Is(err, fs.ErrNotExist) && optionalDataFiles[name] {
+			fmt.Fprintf(&b, "%s:missing;", name)
+			continue
+		}
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(&b, "%s:%d:%d;", name, info.ModTime().UnixNano(), info.Size())
+	}
+	return b.String(), nil
+}
+
+// arcSummary is an Arc without its episode list, for the /arcs listing.
+type arcSummary struct {
+	model.Arc
+	Episodes     []model.Episode `json:"episodes,omitempty"`
+	EpisodeCount int             `json:"episode_count"`
+}
+
+func summarize(arcs []model.Arc) []arcSummary {
+	out := make([]arcSummary, 0, len(arcs))
+	for _, arc := range arcs {
+		out = append(out, arcSummary{Arc: arc, EpisodeCount: len(arc.Episodes)})
+	}
+	return out
+}
@@ -0,0 +1,291 @@
+// Package server is a read-only HTTP API over the exported dataset
+// (data/*.json), so consumers can look up arcs, episodes, CRCs and releases
+// without downloading and indexing the raw JSON maps themselves. It also
+// mounts the Torznab indexer (see internal/torznab) at /torznab/api.
+package server
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	_ "embed"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"metadata-service/internal/model"
+)
+
+//go:embed openapi.yaml
+var openAPISpec []byte
+
+// Server holds the current Dataset and swaps it out when the files on disk
+// change (see Watch).
+type Server struct {
+	dir string
+
+	mu   sync.RWMutex
+	data *Dataset
+}
+
+// New loads the dataset in dir. It fails if the data can't be loaded, since
+// there's nothing useful to serve without it.
+func New(dir string) (*Server, error) {
+	data, err := LoadDataset(dir)
+	if err != nil {
+		return nil, fmt.Errorf("load dataset: %w", err)
+	}
+	return &Server{dir: dir, data: data}, nil
+}
+
+func (s *Server) dataset() *Dataset {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.data
+}
+
+// Reload re-reads the dataset if any data file changed since the last load.
+// On error the previous dataset stays in place. Reports whether a new
+// dataset was swapped in.
+func (s *Server) Reload() (bool, error) {
+	stamp, err := dataStamp(s.dir)
+	if err != nil {
+		return false, err
+	}
+	if stamp == s.dataset().stamp {
+		return false, nil
+	}
+
+	data, err := LoadDataset(s.dir)
+	if err != nil {
+		return false, err
+	}
+	s.mu.Lock()
+	s.data = data
+	s.mu.Unlock()
+	return true, nil
+}
+
+// Watch polls the data directory every interval and hot-reloads on change,
+// until ctx is done. Polling (rather than inotify) keeps this dependency-free
+// and works the same on network mounts and after a `git pull`.
+func (s *Server) Watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			reloaded, err := s.Reload()
+			if err != nil {
+				fmt.Println("Warning: dataset reload failed:", err)
+				continue
+			}
+			if reloaded {
+				fmt.Println("Dataset reloaded from", s.dir)
+			}
+		}
+	}
+}
+
+// Handler returns the API's routes wrapped in CORS handling.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /arcs", s.handleArcs)
+	mux.HandleFunc("GET /arcs/{id}", s.handleArc)
+	mux.HandleFunc("GET /sagas", s.handleSagas)
+	mux.HandleFunc("GET /episodes/{episodeID}", s.handleEpisode)
+	mux.HandleFunc("GET /episodes/{episodeID}/history", s.handleEpisodeHistory)
+	mux.HandleFunc("GET /crc/{crc32}", s.handleCRC)
+	mux.HandleFunc("GET /releases/{infohash}", s.handleRelease)
+	mux.HandleFunc("GET /current", s.handleCurrent)
+	mux.HandleFunc("GET /torznab/api", func(w http.ResponseWriter, r *http.Request) {
+		s.dataset().torznab.ServeHTTP(w, r)
+	})
+	mux.HandleFunc("GET /openapi.yaml", func(w http.ResponseWriter, r *http.Request) {
+		writeBody(w, r, "application/yaml", openAPISpec)
+	})
+	return withCORS(mux)
+}
+
+//
+// ===== HANDLERS =====
+//
+
+// handleArcs lists arcs, optionally filtered by ?audio= and/or ?subtitles=
+// language tags (see hasLanguage). Partial coverage counts as available;
+// the entry's Episodes annotation says which episodes it covers.
+func (s *Server) handleArcs(w http.ResponseWriter, r *http.Request) {
+	audio := r.URL.Query().Get("audio")
+	subtitles := r.URL.Query().Get("subtitles")
+
+	arcs := s.dataset().Arcs
+	if audio != "" || subtitles != "" {
+		var filtered []model.Arc
+		for _, arc := range arcs {
+			if audio != "" && !hasLanguage(arc.AudioLanguageList, audio) {
+				continue
+			}
+			if subtitles != "" && !hasLanguage(arc.SubtitleLanguageList, subtitles) {
+				continue
+			}
+			filtered = append(filtered, arc)
+		}
+		arcs = filtered
+	}
+	writeJSON(w, r, summarize(arcs))
+}
+
+func (s *Server) handleArc(w http.ResponseWriter, r *http.Request) {
+	arc, ok := s.dataset().arcsByID[r.PathValue("id")]
+	if !ok {
+		writeNotFound(w, "arc")
+		return
+	}
+	writeJSON(w, r, arc)
+}
+
+// handleSagas lists the sagas with the IDs of their arcs, in order.
+func (s *Server) handleSagas(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, r, s.dataset().Sagas)
+}
+
+func (s *Server) handleEpisode(w http.ResponseWriter, r *http.Request) {
+	ep, ok := s.dataset().episodesByID[r.PathValue("episodeID")]
+	if !ok {
+		writeNotFound(w, "episode")
+		return
+	}
+	writeJSON(w, r, ep)
+}
+
+// handleEpisodeHistory lists every file released for an episode, oldest
+// first (see export.BuildEpisodeHistory).
+func (s *Server) handleEpisodeHistory(w http.ResponseWriter, r *http.Request) {
+	h, ok := s.dataset().history[r.PathValue("episodeID")]
+	if !ok {
+		writeNotFound(w, "episode")
+		return
+	}
+	writeJSON(w, r, h)
+}
+
+func (s *Server) handleCRC(w http.ResponseWriter, r *http.Request) {
+	entry, ok := s.dataset().Episodes[strings.ToUpper(r.PathValue("crc32"))]
+	if !ok {
+		writeNotFound(w, "crc32")
+		return
+	}
+	writeJSON(w, r, entry)
+}
+
+func (s *Server) handleRelease(w http.ResponseWriter, r *http.Request) {
+	release, ok := s.dataset().Releases[strings.ToLower(r.PathValue("infohash"))]
+	if !ok {
+		writeNotFound(w, "release")
+		return
+	}
+	writeJSON(w, r, release)
+}
+
+func (s *Server) handleCurrent(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, r, s.dataset().Current)
+}
+
+// hasLanguage reports whether langs includes tag, compared
+// case-insensitively. A bare language ("pt") also matches regional
+// variants ("pt-BR"); a regional tag only matches itself.
+func hasLanguage(langs []model.Language, tag string) bool {
+	for _, l := range langs {
+		if strings.EqualFold(l.Tag, tag) {
+			return true
+		}
+		if primary, _, ok := strings.Cut(l.Tag, "-"); ok && strings.EqualFold(primary, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+//
+// ===== RESPONSE HELPERS =====
+//
+
+func writeJSON(w http.ResponseWriter, r *http.Request, v any) {
+	body, err := json.Marshal(v)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeBody(w, r, "application/json", body)
+}
+
+func writeNotFound(w http.ResponseWriter, what string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusNotFound)
+	_ = json.NewEncoder(w).Encode(map[string]string{"error": what + " not found"})
+}
+
+// writeBody sends body with a content-hash ETag, answering a matching
+// If-None-Match with 304, and gzips it when the client accepts gzip.
+func writeBody(w http.ResponseWriter, r *http.Request, contentType string, body []byte) {
+	sum := sha256.Sum256(body)
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+
+	h := w.Header()
+	h.Set("ETag", etag)
+	h.Set("Vary", "Accept-Encoding")
+	if etagMatches(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	h.Set("Content-Type", contentType)
+
+	if !strings.Contains(r.Header.Get("Accept-Encoding"), "gzip") {
+		_, _ = w.Write(body)
+		return
+	}
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	_, _ = gz.Write(body)
+	_ = gz.Close()
+	h.Set("Content-Encoding", "gzip")
+	_, _ = w.Write(buf.Bytes())
+}
+
+// etagMatches implements If-None-Match's weak comparison over a
+// comma-separated list of entity tags (or "*").
+func etagMatches(header, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// withCORS allows any origin to read the API — it's public, read-only data —
+// and answers preflight requests directly.
+func withCORS(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h := w.Header()
+		h.Set("Access-Control-Allow-Origin", "*")
+		h.Set("Access-Control-Expose-Headers", "ETag")
+		if r.Method == http.MethodOptions {
+			h.Set("Access-Control-Allow-Methods", "GET, OPTIONS")
+			h.Set("Access-Control-Allow-Headers", "If-None-Match")
+			h.Set("Access-Control-Max-Age", "86400")
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,235 @@
+package server
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"metadata-service/internal/export"
+	"metadata-service/internal/model"
+)
+
+func writeFixture(t *testing.T, dir string, arcs []model.Arc) {
+	t.Helper()
+	files := map[string]any{
+		"arcs.json": arcs,
+		"episodes.json": export.EpisodesArchive{
+			"E5F09F49": {EpisodeID: "arc1-001", File: model.EpisodeFile{CRC32: "E5F09F49"}},
+		},
+		"episodes-current.json": map[string]model.CurrentEpisode{"arc1-001": {EpisodeID: "arc1-001"}},
+		"releases.json": export.ReleasesArchive{
+			"abc123": {Title: "Romance Dawn 01", InfoHash: "abc123", CRC32: "E5F09F49"},
+		},
+	}
+	for name, v := range files {
+		raw, err := json.Marshal(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, name), raw, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func fixtureArcs(title string) []model.Arc {
+	return []model.Arc{
+		{
+			ID:                   "arc1",
+			Arc:                  1,
+			Title:                title,
+			AudioLanguageList:    []model.Language{{Tag: "ja"}, {Tag: "en"}},
+			SubtitleLanguageList: []model.Language{{Tag: "en"}, {Tag: "pt-BR"}},
+			Episodes:             []model.Episode{{ID: "arc1-001", Arc: 1, Episode: 1, Title: "Episode One"}},
+		},
+		{
+			ID:                   "arc2",
+			Arc:                  2,
+			Title:                "Orange Town",
+			AudioLanguageList:    []model.Language{{Tag: "ja"}},
+			SubtitleLanguageList: []model.Language{{Tag: "en"}},
+		},
+	}
+}
+
+func get(t *testing.T, h http.Handler, path string, header map[string]string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest("GET", path, nil)
+	for k, v := range header {
+		req.Header.Set(k, v)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestRoutes(t *testing.T) {
+	dir := t.TempDir()
+	writeFixture(t, dir, fixtureArcs("Romance Dawn"))
+	srv, err := New(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := srv.Handler()
+
+	cases := []struct {
+		path string
+		want int
+	}{
+		{"/arcs", 200},
+		{"/arcs/arc1", 200},
+		{"/arcs/nope", 404},
+		{"/sagas", 200},
+		{"/episodes/arc1-001", 200},
+		{"/episodes/arc1-001/history", 200},
+		{"/episodes/nope/history", 404},
+		{"/crc/e5f09f49", 200},
+		{"/releases/ABC123", 200},
+		{"/current", 200},
+		{"/openapi.yaml", 200},
+		{"/torznab/api?t=caps", 200},
+	}
+	for _, c := range cases {
+		rec := get(t, h, c.path, nil)
+		if rec.Code != c.want {
+			t.Errorf("GET %s = %d, want %d", c.path, rec.Code, c.want)
+		}
+		if got := rec.Header().Get("Access-Control-Allow-Origin"); got != "*" {
+			t.Errorf("GET %s: missing CORS header", c.path)
+		}
+	}
+
+	var arcs []map[string]any
+	if err := json.Unmarshal(get(t, h, "/arcs", nil).Body.Bytes(), &arcs); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := arcs[0]["episodes"]; ok || arcs[0]["episode_count"] != float64(1) {
+		t.Errorf("/arcs should list summaries without episodes: %v", arcs[0])
+	}
+
+	for query, want := range map[string]int{
+		"/arcs?audio=en":                 1,
+		"/arcs?audio=ja":                 2,
+		"/arcs?subtitles=pt":             1,
+		"/arcs?subtitles=pt-br":          1,
+		"/arcs?audio=ja&subtitles=pt-BR": 1,
+		"/arcs?audio=es":                 0,
+	} {
+		var got []map[string]any
+		if err := json.Unmarshal(get(t, h, query, nil).Body.Bytes(), &got); err != nil {
+			t.Fatal(err)
+		}
+		if len(got) != want {
+			t.Errorf("GET %s returned %d arcs, want %d", query, len(got), want)
+		}
+	}
+}
+
+func TestETagAndGzip(t *testing.T) {
+	dir := t.TempDir()
+	writeFixture(t, dir, fixtureArcs("Romance Dawn"))
+	srv, err := New(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := srv.Handler()
+
+	first := get(t, h, "/arcs/arc1", nil)
+	etag := first.Header().Get("ETag")
+	if etag == "" {
+		t.Fatal("missing ETag")
+	}
+	if rec := get(t, h, "/arcs/arc1", map[string]string{"If-None-Match": etag}); rec.Code != http.StatusNotModified {
+		t.Errorf("If-None-Match = %d, want 304", rec.Code)
+	}
+
+	rec := get(t, h, "/arcs/arc1", map[string]string{"Accept-Encoding": "gzip"})
+	if rec.Header().Get("Content-Encoding") != "gzip" {
+		t.Fatal("expected gzip response")
+	}
+	zr, err := gzip.NewReader(rec.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	plain, err := io.ReadAll(zr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(plain) != first.Body.String() {
+		t.Error("gzipped body differs from plain body")
+	}
+
+	pre := httptest.NewRequest(http.MethodOptions, "/arcs", nil)
+	preRec := httptest.NewRecorder()
+	h.ServeHTTP(preRec, pre)
+	if preRec.Code != http.StatusNoContent {
+		t.Errorf("preflight = %d, want 204", preRec.Code)
+	}
+}
+
+func TestReload(t *testing.T) {
+	dir := t.TempDir()
+	writeFixture(t, dir, fixtureArcs("Romance Dawn"))
+	srv, err := New(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if reloaded, err := srv.Reload(); err != nil || reloaded {
+		t.Fatalf("Reload with no change = %v, %v", reloaded, err)
+	}
+
+	writeFixture(t, dir, fixtureArcs("Romance Dawn (Remastered)"))
+	// Make sure the mtime moves even on coarse-grained filesystems.
+	later := time.Now().Add(time.Minute)
+	if err := os.Chtimes(filepath.Join(dir, "arcs.json"), later, later); err != nil {
+		t.Fatal(err)
+	}
+
+	if reloaded, err := srv.Reload(); err != nil || !reloaded {
+		t.Fatalf("Reload after change = %v, %v", reloaded, err)
+	}
+	if got := srv.dataset().arcsByID["arc1"].Title; got != "Romance Dawn (Remastered)" {
+		t.Errorf("title after reload = %q", got)
+	}
+}
+
+func TestReloadTrackers(t *testing.T) {
+	dir := t.TempDir()
+	writeFixture(t, dir, fixtureArcs("Romance Dawn"))
+	releases, err := json.Marshal(export.ReleasesArchive{
+		"abc123": {Title: "Romance Dawn 01", InfoHash: "abc123", CRC32: "E5F09F49", MagnetURI: "magnet:?xt=urn:btih:abc123"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "releases.json"), releases, 0644); err != nil {
+		t.Fatal(err)
+	}
+	// No trackers.json yet: served with the configured trackers.
+	srv, err := New(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	registry, err := json.Marshal([]model.Tracker{{URL: "udp://tracker.example:80/announce", Releases: 1, Active: true}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "trackers.json"), registry, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if reloaded, err := srv.Reload(); err != nil || !reloaded {
+		t.Fatalf("Reload after trackers.json appeared = %v, %v", reloaded, err)
+	}
+	if got := srv.dataset().Releases["abc123"].MagnetURI; !strings.Contains(got, "tracker.example") {
+		t.Errorf("magnet after reload = %s, want the registry's tracker", got)
+	}
+}
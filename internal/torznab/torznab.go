@@ -0,0 +1,282 @@
+// Package torznab serves the exported releases archive through a minimal
+// Torznab API (t=caps, t=search, t=tvsearch), so Sonarr/Prowlarr-style
+// automation can grab One Pace releases from our local data instead of
+// fuzzy-searching Nyaa. Seasons map to arcs and episodes to One Pace
+// episode numbers, joined through the episode archive by CRC32, or by the
+// release's EpisodeID when its CRC isn't archived.
+package torznab
+
+import (
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"metadata-service/internal/export"
+	"metadata-service/internal/model"
+)
+
+const (
+	torznabNS = "http://torznab.com/schemas/2015/feed"
+
+	// Newznab/Torznab standard categories: TV > Anime.
+	categoryTV    = 5000
+	categoryAnime = 5070
+
+	defaultLimit = 100
+	maxLimit     = 500
+)
+
+// Indexer answers Torznab queries over a fixed snapshot of the releases
+// archive. Build a new one (see New) to pick up re-exported data.
+type Indexer struct {
+	items []item // newest first
+}
+
+// item is a release plus the arc/episode numbers it was joined to, if any
+// (0 when neither its CRC nor its EpisodeID is in the episode archive).
+type item struct {
+	release model.Release
+	season  int
+	episode int
+}
+
+// New builds an Indexer from the exported archives. Outdated releases are
+// left out: they've been superseded, and serving them would let automation
+// grab a stale file over the current one. Magnets are served with the
+// given trackers (see export.LoadTrackers).
+func New(episodes export.EpisodesArchive, releases export.ReleasesArchive, trackers []string) *Indexer {
+	byEpisodeID := make(map[string]model.EpisodeArchiveEntry)
+	for _, entry := range episodes {
+		if entry.EpisodeID != "" {
+			byEpisodeID[entry.EpisodeID] = entry
+		}
+	}
+
+	ix := &Indexer{}
+	for _, r := range releases {
+		if r.Status == model.ReleaseOutdated || r.Variant == model.ReleaseOutdated {
+			continue
+		}
+		it := item{release: export.ReleaseWithTrackers(r, trackers)}
+		entry, ok := episodes[r.CRC32]
+		if !ok || r.CRC32 == "" {
+			entry, ok = byEpisodeID[r.EpisodeID]
+		}
+		if ok {
+			it.season = entry.Arc
+			it.episode = entry.Episode
+		}
+		ix.items = append(ix.items, it)
+	}
+	sort.Slice(ix.items, func(a, b int) bool {
+		pa, pb := ix.items[a].release.PublishedAt, ix.items[b].release.PublishedAt
+		if pa.After(pb) || pb.After(pa) {
+			return pa.After(pb)
+		}
+		return ix.items[a].release.InfoHash < ix.items[b].release.InfoHash
+	})
+	return ix
+}
+
+// ServeHTTP dispatches on the Torznab "t" parameter.
+func (ix *Indexer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	switch q.Get("t") {
+	case "caps":
+		writeXML(w, capsResponse())
+	case "search":
+		ix.search(w, q.Get("q"), 0, 0, q)
+	case "tvsearch":
+		season, err := optionalInt(q.Get("season"))
+		if err != nil {
+			writeError(w, 201, "Incorrect parameter: season")
+			return
+		}
+		ep, err := optionalInt(q.Get("ep"))
+		if err != nil {
+			writeError(w, 201, "Incorrect parameter: ep")
+			return
+		}
+		ix.search(w, q.Get("q"), season, ep, q)
+	case "":
+		writeError(w, 200, "Missing parameter: t")
+	default:
+		writeError(w, 202, "No such function")
+	}
+}
+
+func (ix *Indexer) search(w http.ResponseWriter, query string, season, ep int, params url.Values) {
+	limit, offset := defaultLimit, 0
+	if v := params.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			writeError(w, 201, "Incorrect parameter: limit")
+			return
+		}
+		limit = min(n, maxLimit)
+	}
+	if v := params.Get("offset"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			writeError(w, 201, "Incorrect parameter: offset")
+			return
+		}
+		offset = n
+	}
+
+	resp := rssResponse{
+		Version:   "2.0",
+		TorznabNS: torznabNS,
+		Channel:   rssChannel{Title: "One Pace"},
+	}
+
+	if wantsAnime(params.Get("cat")) {
+		terms := strings.Fields(strings.ToLower(query))
+		matched := 0
+		for _, it := range ix.items {
+			if season != 0 && it.season != season {
+				continue
+			}
+			if ep != 0 && it.episode != ep {
+				continue
+			}
+			if !matchesTerms(it, terms) {
+				continue
+			}
+			matched++
+			if matched <= offset || len(resp.Channel.Items) >= limit {
+				continue
+			}
+			resp.Channel.Items = append(resp.Channel.Items, toRSSItem(it))
+		}
+	}
+
+	writeXML(w, resp)
+}
+
+// wantsAnime reports whether a comma-separated "cat" filter includes the
+// categories we publish under. An empty filter means "any category".
+func wantsAnime(cat string) bool {
+	if cat == "" {
+		return true
+	}
+	for _, c := range strings.Split(cat, ",") {
+		switch strings.TrimSpace(c) {
+		case strconv.Itoa(categoryTV), strconv.Itoa(categoryAnime):
+			return true
+		}
+	}
+	return false
+}
+
+// matchesTerms is a plain all-terms-present match over the item's title
+// (see itemTitle), so the show name Sonarr/Prowlarr put in q ("One Pace")
+// matches along with e.g. "drum island 01", "S13E01" or "FD2B4F32".
+func matchesTerms(it item, terms []string) bool {
+	haystack := strings.ToLower(itemTitle(it))
+	for _, t := range terms {
+		if !strings.Contains(haystack, t) {
+			return false
+		}
+	}
+	return true
+}
+
+// itemTitle puts a scene-style SxxEyy marker in front of the release title
+// when the release could be joined to an arc/episode, so clients that parse
+// titles (rather than the season/episode attrs) still line it up. The
+// resolution tag lets them rank encodes of the same episode by quality.
+func itemTitle(it item) string {
+	title := "One Pace - " + it.release.Title
+	if it.season != 0 && it.episode != 0 {
+		title = fmt.Sprintf("One Pace - S%02dE%02d - %s", it.season, it.episode, it.release.Title)
+	}
+	if it.release.Variant == "extended" && !strings.Contains(strings.ToLower(title), "extended") {
+		title += " Extended"
+	}
+	if it.release.Resolution != "" {
+		title += " [" + it.release.Resolution + "]"
+	}
+	if it.release.CRC32 != "" {
+		title += " [" + it.release.CRC32 + "]"
+	}
+	return title
+}
+
+func toRSSItem(it item) rssItem {
+	r := it.release
+	out := rssItem{
+		Title:    itemTitle(it),
+		GUID:     r.InfoHash,
+		Link:     r.TorrentURL,
+		Comments: r.NyaaURL,
+		Category: categoryAnime,
+	}
+	if out.Link == "" {
+		out.Link = r.MagnetURI
+	}
+	if r.PublishedAt.Known() {
+		out.PubDate = r.PublishedAt.Time.UTC().Format(time.RFC1123Z)
+	}
+	// The size comes from the magnet's "xl", when it had one.
+	var size int64
+	if r.Magnet != nil {
+		size = r.Magnet.ExactLength
+	}
+	if r.TorrentURL != "" {
+		out.Enclosure = &rssEnclosure{URL: r.TorrentURL, Length: size, Type: "application/x-bittorrent"}
+	} else if r.MagnetURI != "" {
+		out.Enclosure = &rssEnclosure{URL: r.MagnetURI, Length: size, Type: "application/x-bittorrent;x-scheme-handler/magnet"}
+	}
+
+	attr := func(name, value string) {
+		if value != "" {
+			out.Attrs = append(out.Attrs, torznabAttr{Name: name, Value: value})
+		}
+	}
+	attr("category", strconv.Itoa(categoryTV))
+	attr("category", strconv.Itoa(categoryAnime))
+	attr("infohash", r.InfoHash)
+	attr("magneturl", r.MagnetURI)
+	attr("crc32", r.CRC32)
+	if size > 0 {
+		attr("size", strconv.FormatInt(size, 10))
+	}
+	if it.season != 0 {
+		attr("season", strconv.Itoa(it.season))
+	}
+	if it.episode != 0 {
+		attr("episode", strconv.Itoa(it.episode))
+	}
+	return out
+}
+
+func optionalInt(s string) (int, error) {
+	if s == "" {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid number %q", s)
+	}
+	return n, nil
+}
+
+func writeXML(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+	_, _ = w.Write([]byte(xml.Header))
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	_ = enc.Encode(v)
+}
+
+// writeError reports a Torznab error. Per the spec these go out as a
+// normal 200 response with an <error> body, which is what clients look for.
+func writeError(w http.ResponseWriter, code int, description string) {
+	writeXML(w, errorResponse{Code: code, Description: description})
+}
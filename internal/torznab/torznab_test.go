@@ -0,0 +1,130 @@
+package torznab
+
+import (
+	"encoding/xml"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"metadata-service/internal/export"
+	"metadata-service/internal/model"
+	"metadata-service/internal/parse"
+)
+
+func testIndexer() *Indexer {
+	episodes := export.EpisodesArchive{
+		"FD2B4F32": {Arc: 13, Episode: 1, File: model.EpisodeFile{CRC32: "FD2B4F32"}},
+		"1EF3F26C": {Arc: 35, Episode: 61, File: model.EpisodeFile{CRC32: "1EF3F26C"}},
+	}
+	releases := export.ReleasesArchive{
+		"aaa": {Title: "Drum Island 01", Variant: "regular", CRC32: "FD2B4F32", Resolution: "1080p", InfoHash: "aaa", PublishedAt: published("2022-09-26T12:00:00.000Z"), TorrentURL: "https://nyaa.si/download/1.torrent", MagnetURI: "magnet:?xt=urn:btih:aaa", Magnet: &model.Magnet{InfoHash: "aaa", ExactLength: 734003200}},
+		"bbb": {Title: "Wano 61", Variant: "regular", CRC32: "1EF3F26C", InfoHash: "bbb", PublishedAt: published("2025-01-01T12:00:00.000Z")},
+		"ccc": {Title: "Wano 61", Variant: "outdated", CRC32: "00000000", InfoHash: "ccc", PublishedAt: published("2024-01-01T12:00:00.000Z")},
+		"ddd": {Title: "Gaimon 01", Variant: "regular", InfoHash: "ddd", PublishedAt: published("2020-01-01T12:00:00.000Z")},
+	}
+	return New(episodes, releases, []string{"udp://tracker.example:80/announce"})
+}
+
+type testFeed struct {
+	Items []struct {
+		Title     string `xml:"title"`
+		GUID      string `xml:"guid"`
+		Enclosure struct {
+			Length int64 `xml:"length,attr"`
+		} `xml:"enclosure"`
+		Attrs []struct {
+			Name  string `xml:"name,attr"`
+			Value string `xml:"value,attr"`
+		} `xml:"attr"`
+	} `xml:"channel>item"`
+}
+
+func query(t *testing.T, ix *Indexer, rawQuery string) (string, testFeed) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	ix.ServeHTTP(rec, httptest.NewRequest("GET", "/api?"+rawQuery, nil))
+	body := rec.Body.String()
+	var feed testFeed
+	if strings.Contains(body, "<rss") {
+		if err := xml.Unmarshal(rec.Body.Bytes(), &feed); err != nil {
+			t.Fatalf("decode %q: %v", rawQuery, err)
+		}
+	}
+	return body, feed
+}
+
+func TestCaps(t *testing.T) {
+	body, _ := query(t, testIndexer(), "t=caps")
+	if !strings.Contains(body, `<tv-search available="yes" supportedParams="q,season,ep">`) {
+		t.Errorf("caps missing tv-search: %s", body)
+	}
+}
+
+func TestSearchSkipsOutdatedAndOrdersNewestFirst(t *testing.T) {
+	_, feed := query(t, testIndexer(), "t=search")
+	var guids []string
+	for _, it := range feed.Items {
+		guids = append(guids, it.GUID)
+	}
+	if got := strings.Join(guids, ","); got != "bbb,aaa,ddd" {
+		t.Errorf("items = %s, want bbb,aaa,ddd", got)
+	}
+}
+
+func TestTVSearchBySeasonAndEpisode(t *testing.T) {
+	_, feed := query(t, testIndexer(), "t=tvsearch&season=13&ep=1")
+	if len(feed.Items) != 1 || feed.Items[0].GUID != "aaa" {
+		t.Fatalf("items = %+v, want only aaa", feed.Items)
+	}
+	it := feed.Items[0]
+	if it.Title != "One Pace - S13E01 - Drum Island 01 [1080p] [FD2B4F32]" {
+		t.Errorf("title = %q", it.Title)
+	}
+	attrs := map[string]string{}
+	for _, a := range it.Attrs {
+		attrs[a.Name] = a.Value
+	}
+	if attrs["season"] != "13" || attrs["episode"] != "1" || attrs["crc32"] != "FD2B4F32" || attrs["magneturl"] == "" {
+		t.Errorf("attrs = %v", attrs)
+	}
+	if attrs["size"] != "734003200" || it.Enclosure.Length != 734003200 {
+		t.Errorf("size attr = %q, enclosure length = %d, want 734003200", attrs["size"], it.Enclosure.Length)
+	}
+}
+
+// TestSonarrQuery checks the queries Sonarr and Prowlarr actually send,
+// which include the show name.
+func TestSonarrQuery(t *testing.T) {
+	ix := testIndexer()
+	_, feed := query(t, ix, "t=tvsearch&q=One+Pace&season=13&ep=1&cat=5000,5070")
+	if len(feed.Items) != 1 || feed.Items[0].GUID != "aaa" {
+		t.Errorf("tvsearch q=One Pace S13E01 = %+v, want only aaa", feed.Items)
+	}
+	if _, feed := query(t, ix, "t=search&q=One+Pace"); len(feed.Items) != 3 {
+		t.Errorf("search q=One Pace returned %d items, want 3", len(feed.Items))
+	}
+	if _, feed := query(t, ix, "t=search&q=One+Pace+S35E61"); len(feed.Items) != 1 || feed.Items[0].GUID != "bbb" {
+		t.Errorf("search q=One Pace S35E61 = %+v, want only bbb", feed.Items)
+	}
+}
+
+func TestSearchQueryAndErrors(t *testing.T) {
+	ix := testIndexer()
+	if _, feed := query(t, ix, "t=search&q=wano+61"); len(feed.Items) != 1 {
+		t.Errorf("q=wano 61 returned %d items, want 1", len(feed.Items))
+	}
+	if _, feed := query(t, ix, "t=search&cat=2000"); len(feed.Items) != 0 {
+		t.Errorf("cat=2000 returned %d items, want 0", len(feed.Items))
+	}
+	if body, _ := query(t, ix, "t=movie"); !strings.Contains(body, `code="202"`) {
+		t.Errorf("unknown function: %s", body)
+	}
+	if body, _ := query(t, ix, "t=tvsearch&season=x"); !strings.Contains(body, `code="201"`) {
+		t.Errorf("bad season: %s", body)
+	}
+}
+
+func published(s string) model.Timestamp {
+	ts, _ := parse.Timestamp(s)
+	return ts
+}
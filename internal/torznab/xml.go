@@ -0,0 +1,104 @@
+package torznab
+
+import "encoding/xml"
+
+//
+// ===== t=caps =====
+//
+
+type caps struct {
+	XMLName    xml.Name       `xml:"caps"`
+	Server     capsServer     `xml:"server"`
+	Limits     capsLimits     `xml:"limits"`
+	Searching  capsSearching  `xml:"searching"`
+	Categories []capsCategory `xml:"categories>category"`
+}
+
+type capsServer struct {
+	Title string `xml:"title,attr"`
+}
+
+type capsLimits struct {
+	Max     int `xml:"max,attr"`
+	Default int `xml:"default,attr"`
+}
+
+type capsSearching struct {
+	Search   capsSearch `xml:"search"`
+	TVSearch capsSearch `xml:"tv-search"`
+}
+
+type capsSearch struct {
+	Available       string `xml:"available,attr"`
+	SupportedParams string `xml:"supportedParams,attr"`
+}
+
+type capsCategory struct {
+	ID      int            `xml:"id,attr"`
+	Name    string         `xml:"name,attr"`
+	Subcats []capsCategory `xml:"subcat"`
+}
+
+func capsResponse() caps {
+	return caps{
+		Server: capsServer{Title: "One Pace Metadata"},
+		Limits: capsLimits{Max: maxLimit, Default: defaultLimit},
+		Searching: capsSearching{
+			Search:   capsSearch{Available: "yes", SupportedParams: "q"},
+			TVSearch: capsSearch{Available: "yes", SupportedParams: "q,season,ep"},
+		},
+		Categories: []capsCategory{{
+			ID:      categoryTV,
+			Name:    "TV",
+			Subcats: []capsCategory{{ID: categoryAnime, Name: "TV/Anime"}},
+		}},
+	}
+}
+
+//
+// ===== t=search / t=tvsearch =====
+//
+
+type rssResponse struct {
+	XMLName   xml.Name   `xml:"rss"`
+	Version   string     `xml:"version,attr"`
+	TorznabNS string     `xml:"xmlns:torznab,attr"`
+	Channel   rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title string    `xml:"title"`
+	Items []rssItem `xml:"item"`
+}
+
+type rssItem struct {
+	Title     string        `xml:"title"`
+	GUID      string        `xml:"guid"`
+	Link      string        `xml:"link,omitempty"`
+	Comments  string        `xml:"comments,omitempty"`
+	PubDate   string        `xml:"pubDate,omitempty"`
+	Category  int           `xml:"category"`
+	Enclosure *rssEnclosure `xml:"enclosure"`
+	Attrs     []torznabAttr `xml:"torznab:attr"`
+}
+
+type rssEnclosure struct {
+	URL    string `xml:"url,attr"`
+	Length int64  `xml:"length,attr"`
+	Type   string `xml:"type,attr"`
+}
+
+type torznabAttr struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+}
+
+//
+// ===== errors =====
+//
+
+type errorResponse struct {
+	XMLName     xml.Name `xml:"error"`
+	Code        int      `xml:"code,attr"`
+	Description string   `xml:"description,attr"`
+}
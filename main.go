@@ -1,26 +1,335 @@
 package main
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"metadata-service/internal/config"
+	"metadata-service/internal/coverage"
 	"metadata-service/internal/export"
 	"metadata-service/internal/fetch"
+	"metadata-service/internal/index"
+	"metadata-service/internal/model"
+	"metadata-service/internal/server"
+	"metadata-service/internal/torznab"
 )
 
 func main() {
-	arcs, err := fetch.FetchEpisodeGuideHome()
+	mode := "export"
+	args := os.Args[1:]
+	if len(args) > 0 {
+		mode, args = args[0], args[1:]
+	}
+
+	switch mode {
+	case "export":
+		runExport(args)
+	case "torznab":
+		runTorznab(args)
+	case "serve":
+		runServe(args)
+	case "where":
+		runWhere(args)
+	case "coverage":
+		runCoverage(args)
+	case "backfill-links":
+		runBackfillLinks(args)
+	default:
+		fmt.Fprintf(os.Stderr, "unknown mode %q (want: export, torznab, serve, where, coverage, backfill-links)\n", mode)
+		os.Exit(2)
+	}
+}
+
+// runExport scrapes the sheets and releases feed into ./data. -drift picks
+// what happens when a sheet's layout changed since the last run (see
+// config.SheetDriftPolicy); -tvdb sets the TVDB series anime-list.xml maps
+// onto (see config.OnePaceTVDBID).
+func runExport(args []string) {
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	drift := fs.String("drift", config.SheetDriftPolicy, "on sheet layout changes: fail or continue")
+	fs.StringVar(&config.OnePaceTVDBID, "tvdb", config.OnePaceTVDBID, "TVDB series ID for anime-list.xml (skipped when empty)")
+	_ = fs.Parse(args)
+
+	arcs, guideReport, err := fetch.FetchEpisodeGuideHome()
+	if err != nil {
+		panic(err)
+	}
+	if err := export.CheckSheetLayouts(&guideReport, "./data", *drift); err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		os.Exit(1)
+	}
+
+	err = exportWithReleases("./data", fetch.FetchReleases, func(releases []model.Release) error {
+		if err := export.ExportMetadata(arcs, releases, "./data"); err != nil {
+			return err
+		}
+		return export.WriteGuideReport(guideReport, "./data")
+	})
 	if err != nil {
 		panic(err)
 	}
 
-	releases, err := fetch.FetchReleases()
+	fmt.Println("Metadata export complete.")
+}
+
+// releasesFetcher is fetch.FetchReleases' signature, so tests can stand in
+// for the feed.
+type releasesFetcher func(prev fetch.FeedValidators, known func(infoHash string) bool) ([]model.Release, fetch.FeedValidators, error)
+
+// exportWithReleases fetches the releases feed conditionally, parsing only
+// releases that aren't archived yet (without an archive to add to, it's
+// read in full), and runs exportFn with them. A feed that's unchanged or
+// can't be read leaves exportFn with no releases. The feed's validators
+// are saved only once exportFn succeeded: until this feed's releases are
+// archived, the next run mustn't skip them on a 304.
+func exportWithReleases(dataDir string, fetchReleases releasesFetcher, exportFn func([]model.Release) error) error {
+	feedPath := dataDir + "/" + export.ReleasesFeedFile
+	validators, err := fetch.LoadFeedValidators(feedPath)
 	if err != nil {
+		return err
+	}
+	var known func(string) bool
+	if archived, err := export.LoadReleasesArchive(dataDir + "/releases.json"); err == nil && len(archived) > 0 {
+		known = func(infoHash string) bool { _, ok := archived[infoHash]; return ok }
+	} else {
+		validators = fetch.FeedValidators{}
+	}
+	releases, validators, err := fetchReleases(validators, known)
+	feedRead := err == nil
+	switch {
+	case errors.Is(err, fetch.ErrNotModified):
+		fmt.Println("Releases feed unchanged since the last run.")
+	case err != nil:
 		fmt.Println("Warning: failed to fetch releases feed:", err)
 	}
 
-	err = export.ExportMetadata(arcs, releases, "./data")
+	if err := exportFn(releases); err != nil {
+		return err
+	}
+	if feedRead {
+		return validators.Save(feedPath)
+	}
+	return nil
+}
+
+// runTorznab serves the exported releases archive as a Torznab indexer at
+// /api, for Sonarr/Prowlarr.
+func runTorznab(args []string) {
+	fs := flag.NewFlagSet("torznab", flag.ExitOnError)
+	addr := fs.String("addr", ":9117", "listen address")
+	dataDir := fs.String("data", "./data", "exported data directory")
+	_ = fs.Parse(args)
+
+	episodes, err := export.LoadEpisodesArchive(*dataDir + "/episodes.json")
+	if err != nil {
+		panic(err)
+	}
+	releases, err := export.LoadReleasesArchive(*dataDir + "/releases.json")
+	if err != nil {
+		panic(err)
+	}
+	trackers, err := export.LoadTrackers(*dataDir + "/trackers.json")
 	if err != nil {
 		panic(err)
 	}
 
-	fmt.Println("Metadata export complete.")
+	mux := http.NewServeMux()
+	mux.Handle("/api", torznab.New(episodes, releases, trackers))
+
+	fmt.Printf("Serving Torznab API on %s/api (%d releases)\n", *addr, len(releases))
+	if err := http.ListenAndServe(*addr, mux); err != nil {
+		panic(err)
+	}
+}
+
+// runServe serves the read-only REST API (plus Torznab at /torznab/api)
+// over the exported data, hot-reloading it when the files change.
+func runServe(args []string) {
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+	addr := fs.String("addr", ":8080", "listen address")
+	dataDir := fs.String("data", "./data", "exported data directory")
+	reload := fs.Duration("reload", 10*time.Second, "how often to check the data files for changes")
+	_ = fs.Parse(args)
+
+	srv, err := server.New(*dataDir)
+	if err != nil {
+		panic(err)
+	}
+	go srv.Watch(context.Background(), *reload)
+
+	fmt.Printf("Serving API on %s (OpenAPI spec at /openapi.yaml)\n", *addr)
+	if err := http.ListenAndServe(*addr, srv.Handler()); err != nil {
+		panic(err)
+	}
+}
+
+// runWhere answers "which One Pace episode adapts chapter N / anime
+// episode N?" from the exported reverse indexes:
+//
+//	where chapter 1000
+//	where anime-ep 312
+func runWhere(args []string) {
+	fs := flag.NewFlagSet("where", flag.ExitOnError)
+	dataDir := fs.String("data", "./data", "exported data directory")
+	_ = fs.Parse(args)
+
+	usage := func() {
+		fmt.Fprintln(os.Stderr, "usage: where [-data dir] chapter|anime-ep <number>")
+		os.Exit(2)
+	}
+	if fs.NArg() != 2 {
+		usage()
+	}
+	var file, label string
+	switch fs.Arg(0) {
+	case "chapter", "ch":
+		file, label = index.ChaptersFile, "Chapter"
+	case "anime-ep", "ep":
+		file, label = index.AnimeEpisodesFile, "Anime episode"
+	default:
+		usage()
+	}
+	n, err := strconv.Atoi(fs.Arg(1))
+	if err != nil {
+		usage()
+	}
+
+	ix, err := index.Load(*dataDir + "/index/" + file)
+	if err != nil {
+		panic(err)
+	}
+	arcs, err := export.LoadArcs(*dataDir + "/arcs.json")
+	if err != nil {
+		panic(err)
+	}
+	type located struct {
+		arc model.Arc
+		ep  model.Episode
+	}
+	byID := make(map[string]located)
+	for _, arc := range arcs {
+		for _, ep := range arc.Episodes {
+			byID[ep.ID] = located{arc: arc, ep: ep}
+		}
+	}
+
+	ids, next := ix.Lookup(n)
+	switch {
+	case len(ids) == 0:
+		fmt.Printf("%s %d isn't adapted by any One Pace episode yet.\n", label, n)
+		return
+	case next != 0:
+		fmt.Printf("%s %d isn't adapted by One Pace; the next one that is, %d, is in:\n", label, n, next)
+	default:
+		fmt.Printf("%s %d is in:\n", label, n)
+	}
+	for _, id := range ids {
+		loc, ok := byID[id]
+		if !ok {
+			fmt.Printf("  %s\n", id)
+			continue
+		}
+		fmt.Printf("  %s %02d - %s (%s)\n", loc.arc.Title, loc.ep.Episode, loc.ep.Title, id)
+	}
+}
+
+// runCoverage prints the chapter coverage report (gaps, overlaps, skipped
+// chapters) for the exported arcs. Pass -json for the raw report, the same
+// as data/reports/coverage.json.
+func runCoverage(args []string) {
+	fs := flag.NewFlagSet("coverage", flag.ExitOnError)
+	dataDir := fs.String("data", "./data", "exported data directory")
+	asJSON := fs.Bool("json", false, "print the report as JSON")
+	_ = fs.Parse(args)
+
+	arcs, err := export.LoadArcs(*dataDir + "/arcs.json")
+	if err != nil {
+		panic(err)
+	}
+	report := coverage.Analyze(arcs)
+
+	if *asJSON {
+		out, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			panic(err)
+		}
+		fmt.Println(string(out))
+		return
+	}
+
+	for _, ac := range report.Arcs {
+		if len(ac.Gaps) == 0 && len(ac.Overlaps) == 0 && len(ac.OutOfRange) == 0 && len(ac.Unparsed) == 0 {
+			continue
+		}
+		fmt.Printf("%d - %s\n", ac.Arc, ac.Title)
+		if len(ac.Gaps) > 0 {
+			fmt.Printf("  gaps:         %s\n", formatRanges(ac.Gaps))
+		}
+		for _, o := range ac.Overlaps {
+			fmt.Printf("  overlap:      %s in %s\n", formatRanges([]model.ChapterRange{o.Chapters}), strings.Join(o.EpisodeIDs, ", "))
+		}
+		if len(ac.OutOfRange) > 0 {
+			fmt.Printf("  out of range: %s\n", formatRanges(ac.OutOfRange))
+		}
+		for _, u := range ac.Unparsed {
+			fmt.Printf("  unparsed:     %q\n", u)
+		}
+	}
+	for _, o := range report.CrossArcOverlaps {
+		fmt.Printf("Cross-arc overlap: %s in arcs %s\n", formatRanges([]model.ChapterRange{o.Chapters}), strings.Join(o.ArcIDs, ", "))
+	}
+	if len(report.Skipped) > 0 {
+		fmt.Printf("Skipped chapters: %s\n", formatRanges(report.Skipped))
+	}
+}
+
+// runBackfillLinks fills in missing view/magnet/.torrent links across the
+// whole episode archive (see export.BackfillLinks). -offline skips the Nyaa
+// searches and uses only the exported data.
+func runBackfillLinks(args []string) {
+	fs := flag.NewFlagSet("backfill-links", flag.ExitOnError)
+	dataDir := fs.String("data", "./data", "exported data directory")
+	offline := fs.Bool("offline", false, "don't search Nyaa")
+	_ = fs.Parse(args)
+
+	var nyaa *fetch.NyaaCache
+	if !*offline {
+		var err error
+		if nyaa, err = fetch.LoadNyaaCache(*dataDir + "/" + export.NyaaCacheFile); err != nil {
+			panic(err)
+		}
+	}
+	report, err := export.BackfillLinks(*dataDir, nyaa)
+	if err != nil {
+		panic(err)
+	}
+	if nyaa != nil {
+		if err := nyaa.Save(); err != nil {
+			panic(err)
+		}
+	}
+
+	fmt.Printf("%d archive entries were missing links: %d now complete, %d still missing some (see reports/links.json)\n",
+		report.Incomplete, report.Completed, report.Incomplete-report.Completed)
+}
+
+// formatRanges renders ranges as "1-4, 19".
+func formatRanges(ranges []model.ChapterRange) string {
+	parts := make([]string, 0, len(ranges))
+	for _, r := range ranges {
+		if r.Start == r.End {
+			parts = append(parts, strconv.Itoa(r.Start))
+		} else {
+			parts = append(parts, fmt.Sprintf("%d-%d", r.Start, r.End))
+		}
+	}
+	return strings.Join(parts, ", ")
 }
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"metadata-service/internal/export"
+	"metadata-service/internal/fetch"
+	"metadata-service/internal/model"
+)
+
+// TestExportWithReleasesSavesValidatorsAfterExport checks that the feed's
+// validators are only recorded once its releases are exported.
+func TestExportWithReleasesSavesValidatorsAfterExport(t *testing.T) {
+	dir := t.TempDir()
+	feedPath := filepath.Join(dir, export.ReleasesFeedFile)
+	if err := os.WriteFile(filepath.Join(dir, "releases.json"), []byte(`{"aaa": {"info_hash": "aaa"}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := (fetch.FeedValidators{ETag: `"v1"`}).Save(feedPath); err != nil {
+		t.Fatal(err)
+	}
+
+	var sent fetch.FeedValidators
+	feed := func(err error) releasesFetcher {
+		return func(prev fetch.FeedValidators, known func(string) bool) ([]model.Release, fetch.FeedValidators, error) {
+			sent = prev
+			if known == nil || !known("aaa") {
+				t.Error("archived releases not passed as known")
+			}
+			if err != nil {
+				return nil, prev, err
+			}
+			return []model.Release{{InfoHash: "bbb"}}, fetch.FeedValidators{ETag: `"v2"`}, nil
+		}
+	}
+	saved := func() string {
+		t.Helper()
+		v, err := fetch.LoadFeedValidators(feedPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return v.ETag
+	}
+
+	// A failed export keeps the old validators.
+	exportErr := errors.New("export failed")
+	err := exportWithReleases(dir, feed(nil), func([]model.Release) error { return exportErr })
+	if !errors.Is(err, exportErr) {
+		t.Fatalf("err = %v, want the export's error", err)
+	}
+	if sent.ETag != `"v1"` {
+		t.Errorf("sent validators %+v, want the saved ones", sent)
+	}
+	if got := saved(); got != `"v1"` {
+		t.Errorf("after a failed export, saved ETag = %s, want \"v1\"", got)
+	}
+
+	// So do an unchanged feed and a failed fetch, which export nothing new.
+	for _, fetchErr := range []error{fetch.ErrNotModified, errors.New("unreachable")} {
+		var exported []model.Release
+		err := exportWithReleases(dir, feed(fetchErr), func(r []model.Release) error { exported = r; return nil })
+		if err != nil || len(exported) != 0 {
+			t.Errorf("%v: err = %v, exported %d releases", fetchErr, err, len(exported))
+		}
+		if got := saved(); got != `"v1"` {
+			t.Errorf("%v: saved ETag = %s, want \"v1\"", fetchErr, got)
+		}
+	}
+
+	// A successful export records the new ones.
+	var exported []model.Release
+	if err := exportWithReleases(dir, feed(nil), func(r []model.Release) error { exported = r; return nil }); err != nil {
+		t.Fatal(err)
+	}
+	if len(exported) != 1 {
+		t.Errorf("exported %d releases, want 1", len(exported))
+	}
+	if got := saved(); got != `"v2"` {
+		t.Errorf("saved ETag = %s, want \"v2\"", got)
+	}
+}
+
+// TestExportWithReleasesWithoutArchive checks that without a releases
+// archive the feed is read unconditionally and in full.
+func TestExportWithReleasesWithoutArchive(t *testing.T) {
+	dir := t.TempDir()
+	if err := (fetch.FeedValidators{ETag: `"v1"`}).Save(filepath.Join(dir, export.ReleasesFeedFile)); err != nil {
+		t.Fatal(err)
+	}
+	fetcher := func(prev fetch.FeedValidators, known func(string) bool) ([]model.Release, fetch.FeedValidators, error) {
+		if prev != (fetch.FeedValidators{}) || known != nil {
+			t.Errorf("fetched with validators %+v, known set %v; want neither", prev, known != nil)
+		}
+		return nil, prev, nil
+	}
+	if err := exportWithReleases(dir, fetcher, func([]model.Release) error { return nil }); err != nil {
+		t.Fatal(err)
+	}
+}